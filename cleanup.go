@@ -0,0 +1,50 @@
+package terror
+
+// Cleanup is a stack of cleanup functions to run when a function returns, generalizing
+// CloseAndAppendOnError to anything that can fail, such as flushing a writer, rolling back a
+// transaction or removing a temporary file. Push each cleanup as its resource is acquired and defer
+// Run with a named return variable:
+//
+//	func writeReport(name string) (err error) {
+//		var cleanup terror.Cleanup
+//		defer cleanup.Run(&err)
+//
+//		f, err := os.Create(name)
+//		if err != nil {
+//			return terror.Wrap(err, "creating report")
+//		}
+//		cleanup.Push(f.Close, "closing %s", name)
+//		w := bufio.NewWriter(f)
+//		cleanup.Push(w.Flush, "flushing %s", name)
+//		...
+//	}
+//
+// The zero value is an empty stack ready to use.
+type Cleanup struct {
+	funcs []cleanupFunc
+}
+
+type cleanupFunc struct {
+	fn  func() error
+	err TError
+}
+
+// Push adds fn to the stack. If fn fails when run, its error is wrapped with the location of this
+// call along with the provided message. The format and args are formatted printf style.
+func (c *Cleanup) Push(fn func() error, format string, args ...interface{}) {
+	c.funcs = append(c.funcs, cleanupFunc{fn, std.newError(nil, format, args, 1)})
+}
+
+// Run runs the pushed functions in the reverse order of being pushed, and empties the stack. Every
+// function is run even if others fail, and each failure is appended to *pErr after any error it
+// already holds. The intended use is to defer Run with a named return variable.
+func (c *Cleanup) Run(pErr *error) {
+	for i := len(c.funcs) - 1; i >= 0; i-- {
+		f := c.funcs[i]
+		if err := f.fn(); err != nil {
+			f.err.base = box(err)
+			appendInto(pErr, f.err, 1)
+		}
+	}
+	c.funcs = nil
+}
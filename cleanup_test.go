@@ -0,0 +1,78 @@
+package terror
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ExampleCleanup() {
+	errExample := func() (err error) {
+		var cleanup Cleanup
+		defer cleanup.Run(&err)
+
+		file := allocateExampleResource()
+		cleanup.Push(file.Close, "close file")
+		cleanup.Push(func() error { return errors.New("disk full") }, "flush file")
+
+		return Wrap(file.Use(), "use")
+	}()
+
+	fmt.Println(errExample.Error())
+	// Output: use: could not use; flush file: disk full; close file: could not close
+}
+
+func TestCleanup(t *testing.T) {
+	var order []int
+	step := func(i int, err error) func() error {
+		return func() error {
+			order = append(order, i)
+			return err
+		}
+	}
+
+	var err error
+	var cleanup Cleanup
+	cleanup.Run(&err)
+	assert.NoError(t, err)
+
+	cleanup.Push(step(1, nil), "one")
+	cleanup.Push(step(2, errors.New("two failed")), "step %d", 2)
+	cleanup.Push(step(3, nil), "three")
+	cleanup.Push(step(4, errSentinel), "four")
+	cleanup.Run(&err)
+	assert.Equal(t, []int{4, 3, 2, 1}, order)
+	require.EqualError(t, err, "four: some error; step 2: two failed")
+	assert.ErrorIs(t, err, errSentinel)
+
+	// The stack is emptied by running it.
+	cleanup.Run(&err)
+	assert.Equal(t, []int{4, 3, 2, 1}, order)
+}
+
+func TestCleanup_PrimaryError(t *testing.T) {
+	fn := func() (err error) {
+		var cleanup Cleanup
+		defer cleanup.Run(&err)
+		cleanup.Push(func() error { return errors.New("rollback failed") }, "rollback")
+		return errSentinel
+	}
+	err := fn()
+	assert.EqualError(t, err, "some error; rollback: rollback failed")
+	assert.ErrorIs(t, err, errSentinel)
+}
+
+func TestCleanup_Location(t *testing.T) {
+	var cleanup Cleanup
+	cleanup.Push(func() error { return errSentinel }, "pushed")
+	pushLine := capture(0).Line - 1
+	var err error
+	cleanup.Run(&err)
+
+	var located TError
+	require.True(t, errors.As(err, &located))
+	assert.Equal(t, Location{"github.com/Tanium-OSS/terror/cleanup_test.go", pushLine, "TestCleanup_Location"}, located.Location())
+}
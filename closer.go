@@ -1,9 +1,10 @@
 package terror
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"io"
-
-	"go.uber.org/multierr"
 )
 
 // CloseAndAppendOnError is a helper function that makes it easier to close Closers in defer
@@ -14,7 +15,56 @@ import (
 // always be used with named return variables. See https://play.golang.org/p/ECMc6EfWXxt for
 // examples of what can go wrong if a local variable is used instead.
 func CloseAndAppendOnError(pErr *error, c io.Closer, format string, args ...interface{}) {
-	multierr.AppendInto(pErr, Wrap(c.Close(), format, args...))
+	appendInto(pErr, Wrap(c.Close(), format, args...), 1)
+}
+
+// ContextCloser is implemented by Closers whose Close can be bounded by a context. The
+// context-aware closer helpers call CloseContext instead of Close when it is available.
+type ContextCloser interface {
+	CloseContext(ctx context.Context) error
+}
+
+// CloseWithContext is like CloseAndAppendOnError, but stops waiting for Close once ctx is done, in
+// which case an error wrapping ctx.Err() is appended instead. Close continues to run in the
+// background; its eventual result is passed to Config.AbandonedClose. Closers that implement
+// ContextCloser are instead closed synchronously using CloseContext.
+func CloseWithContext(ctx context.Context, pErr *error, c io.Closer, format string, args ...interface{}) {
+	appendInto(pErr, closeContext(ctx, c, format, args, 1), 1)
+}
+
+// closeContext closes c, giving up once ctx is done. A failure is wrapped with the location of the
+// caller skip frames above the caller of closeContext, along with the provided message.
+func closeContext(ctx context.Context, c io.Closer, format string, args []interface{}, skip int) error {
+	located := std.newError(nil, format, args, skip+1)
+	wrap := func(err error) error {
+		if err == nil {
+			return nil
+		}
+		wrapped := located
+		wrapped.base = box(err)
+		return wrapped
+	}
+	if cc, ok := c.(ContextCloser); ok {
+		return wrap(cc.CloseContext(ctx))
+	}
+	if ctx.Done() == nil {
+		// ctx can never be done.
+		return wrap(c.Close())
+	}
+	result := make(chan error, 1)
+	go func() { result <- c.Close() }()
+	select {
+	case err := <-result:
+		return wrap(err)
+	case <-ctx.Done():
+		go func() {
+			err := wrap(<-result)
+			if hook := loadConfig().AbandonedClose; hook != nil {
+				hook(c, err)
+			}
+		}()
+		return wrap(fmt.Errorf("gave up waiting for close: %w", ctx.Err()))
+	}
 }
 
 // CloseAndLogOnError is a helper function that makes it easier to close Closers in defer
@@ -23,9 +73,71 @@ func CloseAndAppendOnError(pErr *error, c io.Closer, format string, args ...inte
 func CloseAndLogOnError(fn func(template string, args ...interface{}), closers ...io.Closer) {
 	var err error
 	for _, closer := range closers {
-		multierr.AppendInto(&err, closer.Close())
+		appendInto(&err, closer.Close(), 1)
 	}
 	if err != nil {
 		fn("Close operation failed with error: %+v", err)
 	}
 }
+
+// Logger receives the structured records logged by CloseLogger. args are alternating keys and
+// values, as accepted by *slog.Logger, which SlogLogger adapts to this interface; other structured
+// logging libraries can be adapted in the same way.
+type Logger interface {
+	Log(ctx context.Context, level SeverityLevel, msg string, args ...interface{})
+}
+
+// CloseLogger closes Closers and logs each failure as a separate structured record. Like
+// CloseAndLogOnError, use it when a failure to Close() cannot be handled:
+//
+//	defer terror.CloseLogger{Logger: terror.SlogLogger(slog.Default()), Ignore: []error{net.ErrClosed}}.CloseAndLog(conn)
+type CloseLogger struct {
+	// Logger receives a record for each Closer that fails.
+	Logger Logger
+	// Level is the level of the records. It defaults to SeverityError.
+	Level SeverityLevel
+	// Ignore lists expected errors, such as os.ErrClosed or net.ErrClosed, that are not logged.
+	// They are matched using errors.Is.
+	Ignore []error
+}
+
+// CloseAndLog closes each closer in order. Each failure is wrapped with the location of this call
+// and logged with the index and concrete type of its Closer.
+func (l CloseLogger) CloseAndLog(closers ...io.Closer) {
+	l.closeAndLog(context.Background(), closers)
+}
+
+// CloseAndLogWithContext is like CloseAndLog, but stops waiting for each Close once ctx is done.
+// See CloseWithContext.
+func (l CloseLogger) CloseAndLogWithContext(ctx context.Context, closers ...io.Closer) {
+	l.closeAndLog(ctx, closers)
+}
+
+func (l CloseLogger) closeAndLog(ctx context.Context, closers []io.Closer) {
+	level := l.Level
+	if level == 0 {
+		level = SeverityError
+	}
+	for i, closer := range closers {
+		closerType := fmt.Sprintf("%T", closer)
+		err := closeContext(ctx, closer, "close %s", []interface{}{Safe(closerType)}, 2)
+		if err == nil || l.ignored(err) {
+			continue
+		}
+		l.Logger.Log(ctx, level, "Close operation failed",
+			"index", i,
+			"type", closerType,
+			"location", err.(TError).Location().String(),
+			"error", err,
+		)
+	}
+}
+
+func (l CloseLogger) ignored(err error) bool {
+	for _, target := range l.Ignore {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+//go:build go1.21
+
+package terror
+
+import (
+	"context"
+	"log/slog"
+)
+
+// SlogLogger adapts l to the Logger interface used by CloseLogger. Severities are logged at the
+// corresponding slog levels, with SeverityCritical logged above slog.LevelError.
+func SlogLogger(l *slog.Logger) Logger {
+	return slogLogger{l}
+}
+
+type slogLogger struct {
+	l *slog.Logger
+}
+
+func (l slogLogger) Log(ctx context.Context, level SeverityLevel, msg string, args ...interface{}) {
+	l.l.Log(ctx, slogLevel(level), msg, args...)
+}
+
+// slogLevel returns the slog level corresponding to level.
+func slogLevel(level SeverityLevel) slog.Level {
+	switch level {
+	case SeverityInfo:
+		return slog.LevelInfo
+	case SeverityWarning:
+		return slog.LevelWarn
+	case SeverityCritical:
+		return slog.LevelError + 4
+	}
+	return slog.LevelError
+}
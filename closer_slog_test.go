@@ -0,0 +1,39 @@
+//go:build go1.21
+
+package terror
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCloseLogger_Slog(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey || a.Key == "location" {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	cBad := &TestCloser{t: t, errorOnClose: errors.New("badness")}
+	CloseLogger{Logger: SlogLogger(logger), Level: SeverityWarning}.CloseAndLog(cBad)
+	// The error is logged in its detailed format.
+	assert.Regexp(t,
+		`^level=WARN msg="Close operation failed" index=0 type=\*terror.TestCloser error="close \*terror.TestCloser\\n --- at .*closer_slog_test.go:\d+ \(TestCloseLogger_Slog\) ---\\ncaused by badness"\n$`,
+		buf.String(),
+	)
+}
+
+func TestSlogLevel(t *testing.T) {
+	assert.Equal(t, slog.LevelInfo, slogLevel(SeverityInfo))
+	assert.Equal(t, slog.LevelWarn, slogLevel(SeverityWarning))
+	assert.Equal(t, slog.LevelError, slogLevel(SeverityError))
+	assert.Equal(t, slog.LevelError+4, slogLevel(SeverityCritical))
+	assert.Equal(t, slog.LevelError, slogLevel(0))
+}
@@ -1,11 +1,13 @@
 package terror
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"os"
 	"testing"
-
-	"go.uber.org/multierr"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -42,7 +44,7 @@ func ExampleCloseAndAppendOnError() {
 		return nil
 	}()
 
-	fmt.Println(errExample)
+	fmt.Println(errExample.Error())
 	// Output: first use: could not use; close file: could not close
 }
 
@@ -119,5 +121,127 @@ func TestCloseAndLogOnError(t *testing.T) {
 	}
 
 	CloseAndLogOnError(logFn, cBad, cBad2, cGood)
-	assert.EqualError(t, err, multierr.Combine(New(errMessage1), New(errMessage2)).Error())
+	assert.EqualError(t, err, Combine(New(errMessage1), New(errMessage2)).Error())
+}
+
+// testLogger records the records logged to it.
+type testLogger struct {
+	levels []SeverityLevel
+	args   [][]any
+}
+
+func (l *testLogger) Log(_ context.Context, level SeverityLevel, msg string, args ...any) {
+	l.levels = append(l.levels, level)
+	l.args = append(l.args, args)
+}
+
+func TestCloseLogger(t *testing.T) {
+	cBad := &TestCloser{t: t, errorOnClose: errors.New("badness")}
+	cGood := &TestCloser{t: t}
+	cClosed := &TestCloser{t: t, errorOnClose: Wrap(os.ErrClosed, "again")}
+	cBad2 := &TestCloser{t: t, errorOnClose: errSentinel}
+
+	logger := &testLogger{}
+	CloseLogger{Logger: logger, Ignore: []error{os.ErrClosed}}.CloseAndLog(cBad, cGood, cClosed, cBad2)
+	line := capture(0).Line - 1
+	for _, c := range []*TestCloser{cBad, cGood, cClosed, cBad2} {
+		assert.Equal(t, 1, c.CloseCount())
+	}
+
+	require.Len(t, logger.args, 2)
+	assert.Equal(t, []SeverityLevel{SeverityError, SeverityError}, logger.levels)
+	for i, want := range []struct {
+		index int
+		err   string
+	}{{0, "badness"}, {3, "some error"}} {
+		args := logger.args[i]
+		require.Len(t, args, 8)
+		location := fmt.Sprintf("github.com/Tanium-OSS/terror/closer_test.go:%d (TestCloseLogger)", line)
+		assert.Equal(t, []any{"index", want.index, "type", "*terror.TestCloser", "location", location, "error"}, args[:7])
+		assert.EqualError(t, args[7].(error), "close *terror.TestCloser: "+want.err)
+		assert.Equal(t, location, args[7].(Error).Location().String())
+	}
+}
+
+// slowCloser blocks in Close until released.
+type slowCloser struct {
+	release chan struct{}
+	err     error
+}
+
+func (c *slowCloser) Close() error {
+	<-c.release
+	return c.err
+}
+
+// contextCloser records the context it was closed with.
+type contextCloser struct {
+	TestCloser
+	ctx context.Context
+}
+
+func (c *contextCloser) CloseContext(ctx context.Context) error {
+	c.ctx = ctx
+	return c.errorOnClose
+}
+
+func TestCloseWithContext(t *testing.T) {
+	ctx := context.Background()
+
+	var err error
+	CloseWithContext(ctx, &err, &TestCloser{t: t}, "close")
+	assert.NoError(t, err)
+
+	CloseWithContext(ctx, &err, &TestCloser{t: t, errorOnClose: errors.New("badness")}, "close %d", 1)
+	line := capture(0).Line - 1
+	require.EqualError(t, err, "close 1: badness")
+	assert.Equal(t, line, err.(TError).Location().Line)
+
+	ctx, cancel := context.WithCancel(ctx)
+	c := &contextCloser{TestCloser: TestCloser{t: t, errorOnClose: errSentinel}}
+	err = nil
+	CloseWithContext(ctx, &err, c, "close")
+	cancel()
+	assert.Equal(t, ctx, c.ctx)
+	assert.Equal(t, 0, c.closeCount)
+	assert.EqualError(t, err, "close: some error")
+}
+
+func TestCloseWithContext_Timeout(t *testing.T) {
+	defer SetConfig(Config{})
+	type result struct {
+		c   io.Closer
+		err error
+	}
+	results := make(chan result, 1)
+	SetConfig(Config{AbandonedClose: func(c io.Closer, err error) { results <- result{c, err} }})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	c := &slowCloser{release: make(chan struct{}), err: errors.New("finally failed")}
+	err := errSentinel
+	CloseWithContext(ctx, &err, c, "close slowly")
+	line := capture(0).Line - 1
+	require.EqualError(t, err, "some error; close slowly: gave up waiting for close: context deadline exceeded")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorIs(t, err, errSentinel)
+
+	close(c.release)
+	r := <-results
+	assert.Equal(t, c, r.c)
+	require.EqualError(t, r.err, "close slowly: finally failed")
+	assert.Equal(t, line, r.err.(TError).Location().Line)
+}
+
+func TestCloseLogger_WithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c := &slowCloser{release: make(chan struct{})}
+	defer close(c.release)
+
+	logger := &testLogger{}
+	CloseLogger{Logger: logger}.CloseAndLogWithContext(ctx, c)
+	require.Len(t, logger.args, 1)
+	assert.ErrorIs(t, logger.args[0][7].(error), context.Canceled)
+	assert.Equal(t, []any{"index", 0, "type", "*terror.slowCloser", "location"}, logger.args[0][:5])
 }
@@ -0,0 +1,134 @@
+package terror
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Collector collects the errors of work done in parallel, such as processing the items of a batch.
+// Errors with the same Fingerprint are collected once and counted, so that a failure common to
+// many items is reported once. Collector is safe for concurrent use, and the zero value is an empty
+// Collector that stores every distinct error.
+type Collector struct {
+	// Limit caps the number of distinct errors stored. Once reached, errors with a new fingerprint
+	// are only counted. Zero means no limit. Limit must not be changed after the first Add.
+	Limit int
+
+	mu       sync.Mutex
+	total    int
+	overflow int
+	entries  []*collected
+	index    map[string]*collected
+}
+
+// collected is a distinct error stored by a Collector.
+type collected struct {
+	err   error
+	key   string
+	count int
+}
+
+// Add adds err, which occurred processing the item identified by key, to the collected errors. If
+// err is nil, Add does nothing.
+func (c *Collector) Add(err error, key string) {
+	if err == nil {
+		return
+	}
+	fingerprint := Fingerprint(err)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.total++
+	if entry, ok := c.index[fingerprint]; ok {
+		entry.count++
+		return
+	}
+	if c.Limit > 0 && len(c.entries) >= c.Limit {
+		c.overflow++
+		return
+	}
+	if c.index == nil {
+		c.index = make(map[string]*collected)
+	}
+	entry := &collected{err: err, key: key, count: 1}
+	c.entries = append(c.entries, entry)
+	c.index[fingerprint] = entry
+}
+
+// Len returns the number of errors added, including those not stored because of Limit.
+func (c *Collector) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.total
+}
+
+// Err returns an error summarizing the errors collected so far, or nil if there are none. The
+// summary unwraps to the distinct errors, in the order they were first added, so errors.Is and
+// errors.As match any of them.
+func (c *Collector) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.total == 0 {
+		return nil
+	}
+	s := &collectedError{total: c.total, overflow: c.overflow, entries: make([]collected, len(c.entries))}
+	for i, entry := range c.entries {
+		s.entries[i] = *entry
+	}
+	return s
+}
+
+// collectedError is the summary returned by Collector.Err.
+type collectedError struct {
+	total    int
+	overflow int
+	entries  []collected
+}
+
+// Unwrap returns the distinct errors collected.
+func (s *collectedError) Unwrap() []error {
+	errs := make([]error, len(s.entries))
+	for i, entry := range s.entries {
+		errs[i] = entry.err
+	}
+	return errs
+}
+
+// Error returns a one-line summary including the message of the first error collected.
+func (s *collectedError) Error() string {
+	msg := plural(s.total, "error") + " (" + strconv.Itoa(len(s.entries)) + " distinct"
+	if s.overflow > 0 {
+		msg += ", " + strconv.Itoa(s.overflow) + " not retained"
+	}
+	return msg + "), first: " + s.entries[0].err.Error()
+}
+
+// Format implements fmt.Formatter. The "%v" and "%+v" verbs list each distinct error in its
+// detailed format, along with the number of times it occurred and the key of the item it first
+// occurred for. Every other verb prints the summary returned by Error().
+func (s *collectedError) Format(f fmt.State, c rune) {
+	if c != 'v' {
+		io.WriteString(f, s.Error())
+		return
+	}
+	io.WriteString(f, plural(s.total, "error")+" ("+strconv.Itoa(len(s.entries))+" distinct):")
+	for _, entry := range s.entries {
+		detail := fmt.Sprintf("%+v", entry.err)
+		fmt.Fprintf(f, "\n * %s, first for %q:\n   ", plural(entry.count, "occurrence"), entry.key)
+		io.WriteString(f, strings.ReplaceAll(detail, "\n", "\n   "))
+	}
+	if s.overflow > 0 {
+		fmt.Fprintf(f, "\n * %s not retained", plural(s.overflow, "more error"))
+	}
+}
+
+// plural returns n followed by noun, pluralized for n.
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return strconv.Itoa(n) + " " + noun + "s"
+}
@@ -0,0 +1,6 @@
+//go:build !go1.20
+
+package terror
+
+func (s *collectedError) Is(target error) bool       { return anyIs(s.Unwrap(), target) }
+func (s *collectedError) As(target interface{}) bool { return anyAs(s.Unwrap(), target) }
@@ -0,0 +1,108 @@
+package terror
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ExampleCollector() {
+	var c Collector
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if i%3 == 0 {
+				c.Add(Wrap(errors.New("timeout"), "fetch item"), strconv.Itoa(i))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	fmt.Println(c.Err().Error())
+	// Output: 4 errors (1 distinct), first: fetch item: timeout
+}
+
+func TestCollector(t *testing.T) {
+	var c Collector
+	assert.NoError(t, c.Err())
+	c.Add(nil, "ignored")
+	assert.NoError(t, c.Err())
+	assert.Equal(t, 0, c.Len())
+
+	for i := 0; i < 3; i++ {
+		c.Add(Wrap(errSentinel, "processing"), "item-"+strconv.Itoa(i))
+	}
+	c.Add(Wrap(io.ErrUnexpectedEOF, "reading"), "item-3")
+	c.Add(Const("invalid"), "item-4")
+	assert.Equal(t, 5, c.Len())
+
+	err := c.Err()
+	require.EqualError(t, err, "5 errors (3 distinct), first: processing: some error")
+	assert.ErrorIs(t, err, errSentinel)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.ErrorIs(t, err, Const("invalid"))
+
+	// The summary is a snapshot.
+	c.Add(errSentinel, "item-5")
+	assert.EqualError(t, err, "5 errors (3 distinct), first: processing: some error")
+	assert.EqualError(t, c.Err(), "6 errors (4 distinct), first: processing: some error")
+}
+
+func TestCollector_Limit(t *testing.T) {
+	c := Collector{Limit: 2}
+	c.Add(Const("one"), "a")
+	c.Add(Const("two"), "b")
+	c.Add(Const("three"), "c")
+	c.Add(Const("four"), "d")
+	c.Add(Const("one"), "e")
+	assert.Equal(t, 5, c.Len())
+
+	err := c.Err()
+	assert.EqualError(t, err, "5 errors (2 distinct, 2 not retained), first: one")
+	assert.NotErrorIs(t, err, Const("three"))
+	assert.True(t, strings.HasSuffix(fmt.Sprintf("%+v", err), "\n * 2 more errors not retained"))
+}
+
+func TestCollector_Format(t *testing.T) {
+	var c Collector
+	c.Add(New("failed"), "first")
+	c.Add(New("failed"), "second")
+	c.Add(Const("invalid"), "third")
+	err := c.Err()
+
+	assert.Equal(t, err.Error(), fmt.Sprintf("%s", err))
+	assert.Equal(t, fmt.Sprintf("%+v", err), fmt.Sprintf("%v", err))
+
+	lines := strings.Split(fmt.Sprintf("%+v", err), "\n")
+	require.Len(t, lines, 6)
+	assert.Equal(t, "3 errors (2 distinct):", lines[0])
+	assert.Equal(t, ` * 2 occurrences, first for "first":`, lines[1])
+	assert.Equal(t, "   failed", lines[2])
+	assert.Regexp(t, `^    --- at github.com/Tanium-OSS/terror/collector_test.go:\d+ \(TestCollector_Format\) ---$`, lines[3])
+	assert.Equal(t, ` * 1 occurrence, first for "third":`, lines[4])
+	assert.Equal(t, "   invalid", lines[5])
+}
+
+func TestCollector_Concurrent(t *testing.T) {
+	var c Collector
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c.Add(Const("failed "+strconv.Itoa(i%10)), strconv.Itoa(i))
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, 100, c.Len())
+	assert.Len(t, c.Err().(interface{ Unwrap() []error }).Unwrap(), 10) //nolint:errorlint
+}
@@ -0,0 +1,117 @@
+package terror
+
+import (
+	"io"
+	"sync/atomic"
+)
+
+// Config holds the process-wide configuration of this package. It is installed with SetConfig,
+// which may be called at any time, including while other goroutines are creating and formatting
+// errors. Fields left at their zero value take their default.
+type Config struct {
+	// CleanFileName sanitizes the file names of captured locations. It defaults to
+	// ModuleRelativeFileName; FullFileName and BaseFileName are the other built-in strategies.
+	CleanFileName func(filename string) string
+	// CleanFuncName sanitizes the fully-qualified function names of captured locations. It
+	// defaults to removing the package path.
+	CleanFuncName func(funcName string) string
+	// CaptureDepth is the number of additional stack frames to skip when the package-level
+	// functions capture the location of their caller, e.g. because they are always called via a
+	// helper. It does not apply to Wrappers.
+	CaptureDepth int
+	// Formatter renders the detailed format of errors. It defaults to DetailedFormat.
+	Formatter Formatter
+	// Limits caps the size of formatted errors. The zero value imposes no limits.
+	Limits Limits
+	// RecordCreationTime records when each error is created, so that the detailed format shows
+	// the time elapsed between the creation of the root error and each layer wrapping it, e.g. to
+	// see how long an error spent in retries. It is off by default to avoid the cost of reading
+	// the clock.
+	RecordCreationTime bool
+	// DefaultSeverity is the severity of errors that are neither marked with WithSeverity nor
+	// have a code with a registered severity. It defaults to SeverityError.
+	DefaultSeverity SeverityLevel
+	// Passthrough lists sentinel errors that Wrap, Annotate, WrapWithCode, WrapInto and WrapSkip
+	// return unchanged instead of wrapping, so that comparisons such as err == io.EOF keep
+	// working. It defaults to io.EOF; set it to an empty slice to wrap every error. Errors that
+	// wrap a listed error are still wrapped.
+	Passthrough []error
+	// RedactionPolicy decides whether format arguments that are not marked with Safe or Unsafe
+	// may be included in the output of Redacted. It defaults to treating them as unsafe, in which
+	// case the redacted message of an error without marked arguments is only rendered if Redacted
+	// needs it. Setting a policy instead renders it whenever an error is created with arguments,
+	// applying the policy at that time.
+	RedactionPolicy RedactionPolicy
+	// OnPassthrough, if set, is called with each error returned unchanged because of Passthrough
+	// or WrapUnless, along with the location it would have been wrapped at. It is intended for
+	// debugging.
+	OnPassthrough func(err error, loc Location)
+	// OnCreate, if set, is called with each error created by New, NewWithCode, NewSkip, Wrap,
+	// WrapUnless, WrapWithCode, WrapSkip and the equivalent methods of Wrappers and Kinds, along
+	// with the code it was given, or 0, and whether it wraps another error. It is called by the
+	// goroutine creating the error, so it must be fast and safe for concurrent use. Package
+	// terrormetrics uses it to count errors.
+	OnCreate func(err TError, code int, wrapped bool)
+	// AbandonedClose, if set, is called with the eventual result of each Close that
+	// CloseWithContext or CloseLogger.CloseAndLogWithContext stopped waiting for. err is nil if
+	// Close eventually succeeded. It is called from the goroutine that ran Close.
+	AbandonedClose func(c io.Closer, err error)
+}
+
+// Formatter writes the detailed, multi-line representation of err used for the "%v" verb.
+type Formatter func(w io.Writer, err TError)
+
+// DetailedFormat is the default Formatter. It writes the message and location of each layer of
+// err, followed by the error that caused it.
+func DetailedFormat(w io.Writer, err TError) {
+	err.detailedError(w, nil)
+}
+
+var defaultConfig = Config{
+	CleanFileName:   ModuleRelativeFileName,
+	CleanFuncName:   cleanFuncName,
+	DefaultSeverity: SeverityError,
+	Passthrough:     []error{io.EOF},
+}
+
+func init() {
+	// DetailedFormat reads the configuration, so it cannot be part of its initializer.
+	defaultConfig.Formatter = DetailedFormat
+}
+
+var config atomic.Pointer[Config]
+
+// SetConfig atomically replaces the configuration of this package.
+func SetConfig(c Config) {
+	if c.CleanFileName == nil {
+		c.CleanFileName = defaultConfig.CleanFileName
+	}
+	if c.CleanFuncName == nil {
+		c.CleanFuncName = defaultConfig.CleanFuncName
+	}
+	if c.Formatter == nil {
+		c.Formatter = defaultConfig.Formatter
+	}
+	if c.DefaultSeverity == 0 {
+		c.DefaultSeverity = defaultConfig.DefaultSeverity
+	}
+	if c.Passthrough == nil {
+		c.Passthrough = defaultConfig.Passthrough
+	}
+	c.Passthrough = append([]error{}, c.Passthrough...)
+	config.Store(&c)
+}
+
+// GetConfig returns the current configuration of this package, with defaults filled in.
+func GetConfig() Config {
+	c := *loadConfig()
+	c.Passthrough = append([]error{}, c.Passthrough...)
+	return c
+}
+
+func loadConfig() *Config {
+	if c := config.Load(); c != nil {
+		return c
+	}
+	return &defaultConfig
+}
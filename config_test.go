@@ -0,0 +1,60 @@
+package terror
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetConfig(t *testing.T) {
+	defer SetConfig(Config{})
+
+	SetConfig(Config{CleanFileName: BaseFileName})
+	cfg := GetConfig()
+	assert.NotNil(t, cfg.CleanFuncName)
+	assert.NotNil(t, cfg.Formatter)
+	assert.Equal(t,
+		""+
+			"trying something\n"+
+			" --- at testdata_test.go:13 (tryButFail) ---\n"+
+			"caused by some error",
+		fmt.Sprintf("%+v", tryButFail()),
+	)
+
+	SetConfig(Config{
+		CleanFuncName: func(string) string { return "f" },
+		CaptureDepth:  1,
+		Formatter: func(w io.Writer, err TError) {
+			fmt.Fprintf(w, "%s @ %s", err.Message(), err.Location())
+		},
+	})
+	err := wrapMessage(errSentinel, "captured")
+	assert.Equal(t, "captured: some error", err.Error())
+	assert.Regexp(t, `^captured @ github.com/Tanium-OSS/terror/config_test.go:\d+ \(f\)$`, fmt.Sprintf("%v", err))
+}
+
+func TestSetConfig_Race(t *testing.T) {
+	defer SetConfig(Config{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				SetConfig(Config{CleanFileName: BaseFileName})
+				SetConfig(Config{})
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_ = fmt.Sprintf("%+v", tryButFail())
+			}
+		}()
+	}
+	wg.Wait()
+}
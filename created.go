@@ -0,0 +1,30 @@
+package terror
+
+import (
+	"errors"
+	"time"
+)
+
+// Created returns when the root error of err was created: the innermost error in its chain created
+// by this package while Config.RecordCreationTime was set. If there is none, Created returns the
+// zero time.
+func Created(err error) time.Time {
+	var created time.Time
+	for ; err != nil; err = errors.Unwrap(err) {
+		if e, ok := err.(TError); ok && !e.created.IsZero() { //nolint:errorlint
+			created = e.created
+		}
+	}
+	return created
+}
+
+// elapsed formats the time elapsed since the creation of a root error, e.g. "+120ms".
+func elapsed(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		d = d.Round(time.Millisecond)
+	case d >= time.Millisecond:
+		d = d.Round(10 * time.Microsecond)
+	}
+	return "+" + d.String()
+}
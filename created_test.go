@@ -0,0 +1,70 @@
+package terror
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock replaces now with a clock that only moves when advanced, until the test ends.
+func fakeClock(t *testing.T) *time.Time {
+	clock := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	now = func() time.Time { return clock }
+	t.Cleanup(func() { now = time.Now })
+	return &clock
+}
+
+func TestCreated(t *testing.T) {
+	assert.True(t, Created(tryButFail()).IsZero())
+	assert.True(t, Created(nil).IsZero())
+
+	defer SetConfig(Config{})
+	SetConfig(Config{RecordCreationTime: true})
+	clock := fakeClock(t)
+	start := *clock
+
+	err := New("root")
+	*clock = clock.Add(120 * time.Millisecond)
+	err = Wrap(err, "retrying")
+	*clock = clock.Add(2 * time.Second)
+	err = Wrap(fmt.Errorf("fallback: %w", err), "giving up")
+	assert.Equal(t, start, Created(err))
+
+	assert.Regexp(t, ""+
+		`^giving up\n`+
+		` --- at .*/created_test.go:\d+ \(TestCreated\) --- \(\+2.12s\)\n`+
+		`caused by fallback: retrying\n`+
+		` --- at .*/created_test.go:\d+ \(TestCreated\) --- \(\+120ms\)\n`+
+		`caused by root\n`+
+		` --- at .*/created_test.go:\d+ \(TestCreated\) ---$`,
+		fmt.Sprintf("%+v", err))
+
+	// Errors not created by this package have no creation time of their own.
+	assert.Equal(t, *clock, Created(Wrap(errSentinel, "wrapped")))
+}
+
+func TestCreated_Wrapper(t *testing.T) {
+	clock := fakeClock(t)
+	w := NewWrapper(WithCreationTime())
+	err := w.New("root")
+	*clock = clock.Add(1500 * time.Microsecond)
+	err = w.Wrap(Wrap(err, "not recorded"), "recorded")
+	assert.Equal(t, clock.Add(-1500*time.Microsecond), Created(err))
+	assert.Regexp(t, ""+
+		`^recorded\n`+
+		` --- at .* \(TestCreated_Wrapper\) --- \(\+1.5ms\)\n`+
+		`caused by not recorded\n`+
+		` --- at .* \(TestCreated_Wrapper\) ---\n`+
+		`caused by root\n`+
+		` --- at .* \(TestCreated_Wrapper\) ---$`,
+		fmt.Sprintf("%+v", err))
+}
+
+func TestElapsed(t *testing.T) {
+	assert.Equal(t, "+0s", elapsed(0))
+	assert.Equal(t, "+250µs", elapsed(250*time.Microsecond))
+	assert.Equal(t, "+120ms", elapsed(120*time.Millisecond+4*time.Microsecond))
+	assert.Equal(t, "+1.5s", elapsed(1500*time.Millisecond+300*time.Microsecond))
+}
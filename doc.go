@@ -29,11 +29,11 @@
 // will print
 //
 //	initializing system
-//	 --- at path/to/my/pkg/system.go:123 (system.Initialize) ---
+//	 --- at example.com/my/pkg/system.go:123 (system.Initialize) ---
 //	caused by loading config
-//	 --- at path/to/my/pkg/config.go:37 (system.LoadConfig) ---
+//	 --- at example.com/my/pkg/config.go:37 (system.LoadConfig) ---
 //	caused by some error
-//	 --- at path/to/my/pkg/config.go:62 (system.ReadConfig) ---
+//	 --- at example.com/my/pkg/config.go:62 (system.ReadConfig) ---
 //
 // # Error formatting notes
 //
@@ -4,87 +4,85 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"reflect"
 	"runtime"
+	"strconv"
 	"strings"
+	"time"
 )
 
-// CleanFileName is a process global hook that enables sanitizing filenames in
-// stack traces.
-var CleanFileName = func(filename string) string {
-	// Pass filename through by default.
-	return filename
-}
-
 // Wrap annotates the provided error with the file and line of the call along
 // with the provided message. The format and args are formatted printf style.
-// If err is nil, Wrap returns nil.
+// If err is nil, Wrap returns nil. Errors listed in Config.Passthrough, such
+// as io.EOF, are returned unchanged.
 func Wrap(err error, format string, args ...interface{}) error {
-	if err == nil {
-		return nil
-	}
-	return TError{
-		base: err,
-		msg:  fmt.Sprintf(format, args...),
-		loc:  capture(1),
+	if err == nil || std.passthrough(err, nil, 1) {
+		return err
 	}
+	return report(std.newError(err, format, args, 1), 0, true)
 }
 
 // Annotate annotates the provided error with the file and line of the call. If err is nil, Annotate returns nil.
+// Errors listed in Config.Passthrough are returned unchanged.
 func Annotate(err error) error {
-	if err == nil {
-		return nil
-	}
-	return TError{
-		base: err,
-		loc:  capture(1),
+	if err == nil || std.passthrough(err, nil, 1) {
+		return err
 	}
+	return std.newError(err, "", nil, 1)
 }
 
 // WrapWithCode annotates the provided error with the file and line of the call
 // along with the provided message and a specified error code. The error code
 // can be retrieved using GetCode(). The format and args are formatted printf
-// style. If err is nil, Wrap returns nil.
+// style. If err is nil, Wrap returns nil. Errors listed in
+// Config.Passthrough are returned unchanged.
 func WrapWithCode(err error, code int, format string, args ...interface{}) error {
-	if err == nil {
-		return nil
+	if err == nil || std.passthrough(err, nil, 1) {
+		return err
 	}
-	return codeError{TError{
-		base: err,
-		msg:  fmt.Sprintf(format, args...),
-		loc:  capture(1),
-	}, code}
+	return codeError{report(std.newError(err, format, args, 1), code, true), code}
+}
+
+// WrapUnless is like Wrap, but also returns err unchanged if it is one of
+// targets, for sentinel errors that callers compare with == in one-off cases:
+//
+//	return terror.WrapUnless(err, []error{sql.ErrNoRows}, "loading user %d", id)
+func WrapUnless(err error, targets []error, format string, args ...interface{}) error {
+	if err == nil || std.passthrough(err, targets, 1) {
+		return err
+	}
+	return report(std.newError(err, format, args, 1), 0, true)
 }
 
 // New creates an error with the specified message as well as the location of
 // this call. This is a drop-in replacement for fmt.Errorf.
 func New(format string, args ...interface{}) error {
-	return TError{
-		base: nil,
-		msg:  fmt.Sprintf(format, args...),
-		loc:  capture(1),
-	}
+	return report(std.newError(nil, format, args, 1), 0, false)
 }
 
 // NewWithCode creates an error with the specified message as well as the
 // location of this call and a specified error code. The error code can be
 // retrieved using GetCode().
 func NewWithCode(code int, format string, args ...interface{}) error {
-	return codeError{TError{
-		base: nil,
-		msg:  fmt.Sprintf(format, args...),
-		loc:  capture(1),
-	}, code}
+	return codeError{report(std.newError(nil, format, args, 1), code, false), code}
 }
 
-// GetCode returns the error code most recently added via WrapWithCode or
-// NewWithCode. If no error code is present or the error is not created by this
-// package, 0 is returned.
+// GetCode returns the error code most recently added via WrapWithCode,
+// NewWithCode or a Kind. If no error code is present or the error is not
+// created by this package, 0 is returned.
 func GetCode(err error) int {
-	var wrapper codeError
+	var wrapper coder
 	if !errors.As(err, &wrapper) {
 		return 0
 	}
-	return wrapper.code
+	return wrapper.errorCode()
+}
+
+// coder is implemented by the wrappers in this package that carry an error
+// code.
+type coder interface {
+	error
+	errorCode() int
 }
 
 // codeError adds an error code to an error. base may not be nil.
@@ -93,43 +91,236 @@ type codeError struct {
 	code int
 }
 
-func (e codeError) Unwrap() error { return e.base }
-func (e codeError) Error() string { return e.base.Error() }
-func (e codeError) Format(f fmt.State, c rune) {
-	if base, ok := e.base.(fmt.Formatter); ok { //nolint:errorlint
+func (e codeError) Unwrap() error              { return e.base }
+func (e codeError) Error() string              { return e.base.Error() }
+func (e codeError) Is(target error) bool       { return target == CodeTarget(e.code) } //nolint:errorlint
+func (e codeError) errorCode() int             { return e.code }
+func (e codeError) Format(f fmt.State, c rune) { formatBase(e.base, f, c) }
+
+// CodeTarget returns an error that matches, according to errors.Is, any error
+// with the given code anywhere in its chain, whether added by WrapWithCode,
+// NewWithCode or a Kind:
+//
+//	if errors.Is(err, terror.CodeTarget(404)) {
+func CodeTarget(code int) error {
+	return codeTarget(code)
+}
+
+type codeTarget int
+
+func (t codeTarget) Error() string { return "error code " + strconv.Itoa(int(t)) }
+
+// boxedError holds an error whose dynamic type is not comparable, so that the
+// errors wrapping it remain safe to compare with ==, as errors.Is does. It is
+// transparent: wrappers unbox it when unwrapping, and it formats like the
+// error it holds.
+type boxedError struct {
+	err error
+}
+
+func (b *boxedError) Error() string              { return b.err.Error() }
+func (b *boxedError) Format(f fmt.State, c rune) { formatBase(b.err, f, c) }
+
+// box returns err, boxed if its dynamic type is not comparable.
+func box(err error) error {
+	if err != nil && !reflect.TypeOf(err).Comparable() {
+		return &boxedError{err}
+	}
+	return err
+}
+
+// unbox returns the error held by err if it is boxed, or else err.
+func unbox(err error) error {
+	if b, ok := err.(*boxedError); ok { //nolint:errorlint
+		return b.err
+	}
+	return err
+}
+
+// formatBase formats base on behalf of a wrapper that adds no formatting of
+// its own.
+func formatBase(base error, f fmt.State, c rune) {
+	if base, ok := base.(fmt.Formatter); ok { //nolint:errorlint
 		base.Format(f, c)
 		return
 	}
-	fmt.Fprintf(f, origFormatString(f, c), e.base)
+	fmt.Fprintf(f, origFormatString(f, c), base)
+}
+
+// noter is implemented by wrappers that add no text to Error(), but add a note
+// to the detailed format, such as a severity.
+type noter interface {
+	error
+	Unwrap() error
+	note() string
+}
+
+// formatNoted formats base on behalf of a wrapper that adds note to the
+// detailed format, which "%v" prints as well as "%+v".
+func formatNoted(note string, base error, f fmt.State, c rune) {
+	if c == 'v' {
+		io.WriteString(f, noteLine(note)+"\n")
+	}
+	formatBase(base, f, c)
 }
 
-// TError is the wrapped error implementation.
+// noteLine returns the line showing note in the detailed format.
+func noteLine(note string) string {
+	return " --- " + note + " ---"
+}
+
+// TError is the wrapped error implementation. TErrors are safe to compare with
+// ==, as errors.Is does, even when they wrap errors whose types are not
+// comparable.
 type TError struct {
 	base error
 	msg  string
-	loc  Location
+	// format is the printf template that msg was rendered from.
+	format string
+	// redaction is what Redacted needs of the arguments of msg, or nil if
+	// it has none.
+	redaction *redaction
+	loc       Location
+	// wrapper is the Wrapper that created this error, or nil if it was
+	// created by the package-level functions.
+	wrapper *Wrapper
+	// created is when the error was created, if Config.RecordCreationTime
+	// was set.
+	created time.Time
+}
+
+// newError creates a TError located at the caller skip frames above the caller
+// of newError, using the configuration of w or, if w is nil, of the package.
+func (w *Wrapper) newError(base error, format string, args []interface{}, skip int) TError {
+	msg := fmt.Sprintf(format, args...)
+	e := TError{
+		base:      box(base),
+		msg:       msg,
+		format:    format,
+		redaction: newRedaction(msg, format, args, w.redactionPolicy()),
+		loc:       w.capture(skip + 1),
+		wrapper:   w,
+	}
+	if w.recordsCreationTime() {
+		e.created = now()
+	}
+	return e
+}
+
+// report calls Config.OnCreate, if set, with e, which was created with code, and returns e.
+func report(e TError, code int, wrapped bool) TError {
+	if onCreate := loadConfig().OnCreate; onCreate != nil {
+		onCreate(e, code, wrapped)
+	}
+	return e
 }
 
+// now is time.Now, replaceable by tests.
+var now = time.Now
+
 // Unwrap returns the base error, implementing the go1.13 error unwrapping to
 // support errors.Is and errors.As.
-func (e TError) Unwrap() error { return e.base }
+func (e TError) Unwrap() error { return unbox(e.base) }
 
-// Error returns the simple, compact, one-line error format.
+// Error returns the simple, compact, one-line error format: the message of
+// each layer followed by the error it wraps, separated by colons. A message
+// repeated by consecutive layers, e.g. by recursion, is shown once.
 func (e TError) Error() string {
-	// There are three interesting cases here:
-	// 1: root error, created by New(): have message but no base error
-	//    --> just print the message.
+	if limits := e.wrapper.limits(); limits != (Limits{}) {
+		return e.limitedError(limits)
+	}
 	if e.base == nil {
 		return e.msg
 	}
-	// 2: only a stack marker: have a base error but no message
-	//    --> just print the base error
-	if e.msg == "" {
-		return e.base.Error()
+	// Walk the chain like chain does, but without collecting the layers, as
+	// Error is called far more often than the detailed format.
+	var b strings.Builder
+	prev, repeats := "", 1
+	flush := func() {
+		if repeats > 1 {
+			b.WriteString(repeated(repeats))
+			repeats = 1
+		}
+	}
+	write := func(msg string) {
+		if b.Len() > 0 {
+			b.WriteString(": ")
+		}
+		b.WriteString(msg)
 	}
-	// 3: wrapped with message: have a base error AND a message
-	//    --> print `${msg}: ${base}`
-	return e.msg + ": " + e.base.Error()
+	msg, base := e.msg, unbox(e.base)
+	for {
+		switch {
+		case msg == "":
+		case msg == prev:
+			repeats++
+		default:
+			flush()
+			write(msg)
+			prev = msg
+		}
+		msg = ""
+		switch wrapped := base.(type) { //nolint:errorlint
+		case nil:
+			flush()
+			return b.String()
+		case codeError:
+			base = wrapped.base
+		case kindError:
+			base = wrapped.base
+		case noter:
+			base = wrapped.Unwrap()
+		case TError:
+			msg, base = wrapped.msg, unbox(wrapped.base)
+		default:
+			flush()
+			write(wrapped.Error())
+			return b.String()
+		}
+	}
+}
+
+// limitedError is Error for errors created with Limits, which applies them
+// to the layers of the chain.
+func (e TError) limitedError(limits Limits) string {
+	layers := e.chain(nil)
+	msgs := make([]string, 0, len(layers))
+	repeats := 1
+	flush := func() {
+		if repeats > 1 {
+			msgs[len(msgs)-1] += repeated(repeats)
+			repeats = 1
+		}
+	}
+	prev := ""
+	for _, layer := range layers {
+		if layer.root != nil {
+			flush()
+			msgs = append(msgs, truncate(layer.root.Error(), limits.MaxMessageLength))
+			break
+		}
+		if layer.msg == "" {
+			continue
+		}
+		if layer.msg == prev {
+			repeats++
+			continue
+		}
+		flush()
+		prev = layer.msg
+		msgs = append(msgs, truncate(layer.msg, limits.MaxMessageLength))
+	}
+	flush()
+	if head, elided := elide(len(msgs), limits.MaxLayers); elided > 0 {
+		msgs = append(append(msgs[:head:head], elision(elided)), msgs[head+elided:]...)
+	}
+	return truncate(strings.Join(msgs, ": "), limits.MaxLength)
+}
+
+// Message returns the message of this layer of the error, excluding the
+// message of the base error.
+func (e TError) Message() string {
+	return e.msg
 }
 
 // Location returns a string representation of the tError after cleaning file and function names.
@@ -138,28 +329,123 @@ func (e TError) Location() Location {
 }
 
 // detailedError returns the multiline stacktrace-annotated error. This will
-// format the wrapped error recursively.
-func (e TError) detailedError(w io.Writer) {
+// format the wrapped error recursively. If annotate is not nil, it is called
+// after writing each location to add to it.
+func (e TError) detailedError(w io.Writer, annotate func(w io.Writer, loc Location)) {
+	limits := e.wrapper.limits()
+	created := Created(e)
+	// Layers created with a different Formatter are formatted by it instead.
+	layers, repeats := collapse(e.chain(func(layer TError) bool {
+		return layer.wrapper == e.wrapper || !layer.wrapper.hasFormatter() && !e.wrapper.hasFormatter()
+	}))
+	head, elided := elide(len(layers), limits.MaxLayers)
 	sep := ""
-	if e.msg != "" {
-		fmt.Fprintf(w, "%s", e.msg)
-		sep = "\n"
-	}
-	if e.loc != (Location{}) {
-		io.WriteString(w, sep)
-		fmt.Fprintf(w, " --- at %s ---", e.Location().String())
-		sep = "\n"
-	}
-	if ourError, ok := e.base.(TError); ok && ourError.msg == "" { //nolint:errorlint
-		io.WriteString(w, sep)
-		fmt.Fprintf(w, "%+v", e.base)
-	} else if e.base != nil {
+	for i := 0; i < len(layers); i++ {
+		if i == head && elided > 0 {
+			io.WriteString(w, sep+elision(elided))
+			sep = "\n"
+			i += elided - 1
+			continue
+		}
+		layer := layers[i]
 		io.WriteString(w, sep)
-		if sep != "" {
+		for _, note := range layer.notes {
+			io.WriteString(w, noteLine(note)+"\n")
+		}
+		if layer.root != nil {
+			if annotation, ok := layer.root.(TError); sep != "" && (!ok || annotation.msg != "") { //nolint:errorlint
+				fmt.Fprint(w, "caused by ")
+			}
+			io.WriteString(w, truncate(fmt.Sprintf("%+v", layer.root), limits.MaxMessageLength))
+			break
+		}
+		if sep != "" && layer.msg != "" {
 			fmt.Fprint(w, "caused by ")
 		}
-		fmt.Fprintf(w, "%+v", e.base)
+		sep = ""
+		if layer.msg != "" {
+			fmt.Fprintf(w, "%s", truncate(layer.msg, limits.MaxMessageLength))
+			sep = "\n"
+		}
+		if layer.loc != (Location{}) {
+			io.WriteString(w, sep)
+			fmt.Fprintf(w, " --- at %s ---", layer.Location().String())
+			if repeats[i] > 1 {
+				io.WriteString(w, repeated(repeats[i]))
+			}
+			if !layer.created.IsZero() && layer.created != created {
+				io.WriteString(w, " ("+elapsed(layer.created.Sub(created))+")")
+			}
+			if annotate != nil {
+				annotate(w, layer.loc)
+			}
+			sep = "\n"
+		}
+	}
+}
+
+// layer is one layer of an error chain: a TError or, last, the root error if
+// it was not created by this package. notes are added to the layer by the
+// wrappers directly above it.
+type layer struct {
+	TError
+	root  error
+	notes []string
+}
+
+// collapse collapses runs of consecutive layers that were created at the
+// same location from the same message template, such as by recursion or a
+// retry loop, into the first layer of each run. It returns the remaining
+// layers along with the number of layers each represents.
+func collapse(layers []layer) ([]layer, []int) {
+	kept := layers[:0:0]
+	var repeats []int
+	for i, layer := range layers {
+		if i > 0 && layer.root == nil && len(layer.notes) == 0 && layers[i-1].root == nil &&
+			layer.loc != (Location{}) && layer.loc == layers[i-1].loc && layer.format == layers[i-1].format {
+			repeats[len(repeats)-1]++
+			continue
+		}
+		kept = append(kept, layer)
+		repeats = append(repeats, 1)
+	}
+	return kept, repeats
+}
+
+// repeated describes how many times a layer was repeated.
+func repeated(n int) string {
+	return " (repeated " + strconv.Itoa(n) + " times)"
+}
+
+// chain returns the layers of e: e and the TErrors it wraps, skipping
+// wrappers that add no text of their own such as those adding codes or kinds,
+// followed by the first other error, if any. If follow is not nil, it is
+// called for each wrapped TError, and the chain stops at the first that it
+// returns false for.
+func (e TError) chain(follow func(TError) bool) []layer {
+	layers := []layer{{TError: e}}
+	var notes []string
+	for base := unbox(e.base); base != nil; {
+		switch wrapped := base.(type) { //nolint:errorlint
+		case codeError:
+			base = wrapped.base
+		case kindError:
+			base = wrapped.base
+		case noter:
+			notes = append(notes, wrapped.note())
+			base = wrapped.Unwrap()
+		case TError:
+			if follow != nil && !follow(wrapped) {
+				return append(layers, layer{root: wrapped, notes: notes})
+			}
+			layers = append(layers, layer{TError: wrapped, notes: notes})
+			notes = nil
+			base = unbox(wrapped.base)
+		default:
+			return append(layers, layer{root: base, notes: notes})
+		}
 	}
+	return layers
 }
 
 // Format implements fmt.Formatter so that we know when we're being formatted by
@@ -167,7 +453,13 @@ func (e TError) detailedError(w io.Writer) {
 // "%v" in which case we output the detailed stack trace.
 func (e TError) Format(f fmt.State, c rune) {
 	if c == 'v' {
-		e.detailedError(f)
+		if max := e.wrapper.limits().MaxLength; max > 0 {
+			var b strings.Builder
+			e.wrapper.formatter()(&b, e)
+			io.WriteString(f, truncate(b.String(), max))
+		} else {
+			e.wrapper.formatter()(f, e)
+		}
 	} else {
 		io.WriteString(f, e.Error())
 	}
@@ -197,23 +489,6 @@ func RootError(e error) Error {
 	return deepestError
 }
 
-// Adapted from github.com/palantir/stacktrace, this removes the package name
-// from the function name:
-//
-//	github.com/foo/bar/package.FuncName                  --> FuncName
-//	github.com/foo/bar/package.Receiver.MethodName       --> Receiver.MethodName
-//	github.com/foo/bar/package.(*PtrReceiver).MethodName --> PtrReceiver.MethodName
-func cleanFuncName(longName string) string {
-	withoutPath := longName[strings.LastIndex(longName, "/")+1:]
-	withoutPackage := withoutPath[strings.Index(withoutPath, ".")+1:]
-	shortName := withoutPackage
-	if shortName[0] == '(' {
-		pos := strings.IndexByte(shortName, ')')
-		return shortName[2:pos] + shortName[pos+1:]
-	}
-	return shortName
-}
-
 // Location describes a single stack frame position.
 type Location struct {
 	File     string
@@ -226,23 +501,51 @@ func (l Location) String() string {
 	return fmt.Sprintf("%s:%d (%s)", l.File, l.Line, l.Function)
 }
 
-// capture reads the current stack pos.
+// capture reads the current stack pos using the package configuration.
 func capture(skip int) Location {
-	// For reading a single stack frame at a time, the paired usage of
-	// runtime.Caller + runtime.FuncForPC is fine to use and reasonably efficient.
-	// If you want more than one frame (e.g. pulling a stack), it's better to use
-	// runtime.Callers + runtime.CallersFrames.
+	return std.capture(skip + 1)
+}
+
+// capture reads the current stack pos using the configuration of w or, if w is
+// nil, of the package.
+func (w *Wrapper) capture(skip int) Location {
+	cfg := loadConfig()
+	cleanFile, cleanFunc, depth := cfg.CleanFileName, cfg.CleanFuncName, cfg.CaptureDepth
+	if w != nil {
+		if w.cfg.CleanFileName != nil {
+			cleanFile = w.cfg.CleanFileName
+		}
+		if w.cfg.CleanFuncName != nil {
+			cleanFunc = w.cfg.CleanFuncName
+		}
+		depth = w.cfg.CaptureDepth
+	}
+	// runtime.Callers + runtime.CallersFrames account for inlined functions,
+	// which runtime.Caller + runtime.FuncForPC can misattribute since the
+	// return address of a call may belong to a different inlined function.
 	//
 	// For efficiency in the future: it's probably better to pull entire stacks
 	// via runtime.Callers and defer resolving the file/line/function information
-	// until we're printing the error out. However, runtime.Callers seems a little
-	// wonky when reading only a single stack frame.
-	pc, file, line, ok := runtime.Caller(skip + 1)
-	if !ok {
+	// until we're printing the error out.
+	//
+	// Frames of functions marked by Helper are skipped, up to the size of buf.
+	var buf [16]uintptr
+	pcs := buf[:1]
+	if helpersMarked.Load() {
+		pcs = buf[:]
+	}
+	n := runtime.Callers(skip+depth+2, pcs)
+	if n == 0 {
 		return Location{}
 	}
-	function := runtime.FuncForPC(pc).Name()
-	return Location{CleanFileName(file), line, cleanFuncName(function)}
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !more || !isHelper(frame.Function) {
+			learnMainModule(frame.File, frame.Function)
+			return Location{cleanFile(frame.File), frame.Line, cleanFunc(frame.Function)}
+		}
+	}
 }
 
 // Adapted from github.com/palantir/stacktrace, this reconstructs the format
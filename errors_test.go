@@ -1,36 +1,16 @@
 package terror
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"path/filepath"
+	"io"
 	"regexp"
-	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
-	"go.uber.org/multierr"
 )
 
-// When running tests, we'll probably not have the -trimpath arg specified (for
-// example, when clicking the "run test" in vscode). We want to ensure that the
-// tests produce deterministic output, though! So we'll be sneaky: Before any of
-// the tests run, we'll capture a local stack trace and use that to determine
-// the root of the repo and trim that off of our stack traces.
-func init() {
-	// path/to/repo/terror/errors_test.go or pkg.com/repo/terror/errors_test.go
-	thisFile := capture(0).File
-	// path/to/repo/terror or pkg.com/repo/terror
-	thisDir := filepath.Dir(thisFile)
-	// path/to/repo/ or pkg.com/repo/ (with trailing / or \)
-	repoRoot := strings.TrimSuffix(thisDir, "terror")
-	// Now configure our filename cleaning to remove the repo root. This works
-	// since we're only ever dealing with files in this dir for this test.
-	CleanFileName = func(filename string) string {
-		return strings.TrimPrefix(filename, repoRoot)
-	}
-}
-
 func TestWrapNil(t *testing.T) {
 	assert.Nil(t, Wrap(nil, ""))
 	assert.Nil(t, Wrap(nil, "something"))
@@ -43,7 +23,7 @@ func TestWrap(t *testing.T) {
 	assert.Equal(t,
 		""+
 			"trying something\n"+
-			" --- at terror/testdata_test.go:13 (tryButFail) ---\n"+
+			" --- at github.com/Tanium-OSS/terror/testdata_test.go:13 (tryButFail) ---\n"+
 			"caused by some error",
 		fmt.Sprintf("%+v", err),
 	)
@@ -54,10 +34,10 @@ func TestWrap(t *testing.T) {
 	assert.Equal(t,
 		""+
 			"wrap with 2 message(s)\n"+
-			" --- at terror/testdata_test.go:18 (wrapMessage) ---\n"+
-			" --- at terror/testdata_test.go:16 (wrapNoMessage) ---\n"+
+			" --- at github.com/Tanium-OSS/terror/testdata_test.go:18 (wrapMessage) ---\n"+
+			" --- at github.com/Tanium-OSS/terror/testdata_test.go:16 (wrapNoMessage) ---\n"+
 			"caused by trying something\n"+
-			" --- at terror/testdata_test.go:13 (tryButFail) ---\n"+
+			" --- at github.com/Tanium-OSS/terror/testdata_test.go:13 (tryButFail) ---\n"+
 			"caused by some error",
 		fmt.Sprintf("%+v", err),
 	)
@@ -66,12 +46,12 @@ func TestWrap(t *testing.T) {
 	assert.Equal(t, "wrap with 2 message(s): trying something: some error", err.Error())
 	assert.Equal(t,
 		""+
-			" --- at terror/testdata_test.go:16 (wrapNoMessage) ---\n"+
+			" --- at github.com/Tanium-OSS/terror/testdata_test.go:16 (wrapNoMessage) ---\n"+
 			"caused by wrap with 2 message(s)\n"+
-			" --- at terror/testdata_test.go:18 (wrapMessage) ---\n"+
-			" --- at terror/testdata_test.go:16 (wrapNoMessage) ---\n"+
+			" --- at github.com/Tanium-OSS/terror/testdata_test.go:18 (wrapMessage) ---\n"+
+			" --- at github.com/Tanium-OSS/terror/testdata_test.go:16 (wrapNoMessage) ---\n"+
 			"caused by trying something\n"+
-			" --- at terror/testdata_test.go:13 (tryButFail) ---\n"+
+			" --- at github.com/Tanium-OSS/terror/testdata_test.go:13 (tryButFail) ---\n"+
 			"caused by some error",
 		fmt.Sprintf("%+v", err),
 	)
@@ -82,12 +62,12 @@ func TestWrap(t *testing.T) {
 	)
 	assert.Equal(t,
 		""+
-			" --- at terror/testdata_test.go:16 (wrapNoMessage) ---\n"+
+			" --- at github.com/Tanium-OSS/terror/testdata_test.go:16 (wrapNoMessage) ---\n"+
 			"caused by wrap with 2 message(s)\n"+
-			" --- at terror/testdata_test.go:18 (wrapMessage) ---\n"+
-			" --- at terror/testdata_test.go:16 (wrapNoMessage) ---\n"+
+			" --- at github.com/Tanium-OSS/terror/testdata_test.go:18 (wrapMessage) ---\n"+
+			" --- at github.com/Tanium-OSS/terror/testdata_test.go:16 (wrapNoMessage) ---\n"+
 			"caused by trying something\n"+
-			" --- at terror/testdata_test.go:13 (tryButFail) ---\n"+
+			" --- at github.com/Tanium-OSS/terror/testdata_test.go:13 (tryButFail) ---\n"+
 			"caused by some error",
 		fmt.Sprint(err),
 	)
@@ -97,23 +77,23 @@ func TestWrap(t *testing.T) {
 	)
 	assert.Equal(t,
 		""+
-			" --- at terror/testdata_test.go:16 (wrapNoMessage) ---\n"+
+			" --- at github.com/Tanium-OSS/terror/testdata_test.go:16 (wrapNoMessage) ---\n"+
 			"caused by wrap with 2 message(s)\n"+
-			" --- at terror/testdata_test.go:18 (wrapMessage) ---\n"+
-			" --- at terror/testdata_test.go:16 (wrapNoMessage) ---\n"+
+			" --- at github.com/Tanium-OSS/terror/testdata_test.go:18 (wrapMessage) ---\n"+
+			" --- at github.com/Tanium-OSS/terror/testdata_test.go:16 (wrapNoMessage) ---\n"+
 			"caused by trying something\n"+
-			" --- at terror/testdata_test.go:13 (tryButFail) ---\n"+
+			" --- at github.com/Tanium-OSS/terror/testdata_test.go:13 (tryButFail) ---\n"+
 			"caused by some error",
 		fmt.Sprintf("%v", err),
 	)
 	assert.Equal(t,
 		""+
-			" --- at terror/testdata_test.go:16 (wrapNoMessage) ---\n"+
+			" --- at github.com/Tanium-OSS/terror/testdata_test.go:16 (wrapNoMessage) ---\n"+
 			"caused by wrap with 2 message(s)\n"+
-			" --- at terror/testdata_test.go:18 (wrapMessage) ---\n"+
-			" --- at terror/testdata_test.go:16 (wrapNoMessage) ---\n"+
+			" --- at github.com/Tanium-OSS/terror/testdata_test.go:18 (wrapMessage) ---\n"+
+			" --- at github.com/Tanium-OSS/terror/testdata_test.go:16 (wrapNoMessage) ---\n"+
 			"caused by trying something\n"+
-			" --- at terror/testdata_test.go:13 (tryButFail) ---\n"+
+			" --- at github.com/Tanium-OSS/terror/testdata_test.go:13 (tryButFail) ---\n"+
 			"caused by some error",
 		fmt.Sprintf("%#v", err),
 	)
@@ -129,7 +109,7 @@ func TestWrapWithFormat(t *testing.T) {
 	assert.Equal(t,
 		""+
 			"trying something\n"+
-			" --- at terror/testdata_test.go:14 (tryButFailf) ---\n"+
+			" --- at github.com/Tanium-OSS/terror/testdata_test.go:14 (tryButFailf) ---\n"+
 			"caused by some error",
 		fmt.Sprintf("%+v", err),
 	)
@@ -142,9 +122,9 @@ func TestWrap_MethodNames(t *testing.T) {
 	assert.Equal(t,
 		""+
 			"checking pointer receiver\n"+
-			" --- at terror/testdata_test.go:27 (SomeType.wrapPtr) ---\n"+
+			" --- at github.com/Tanium-OSS/terror/testdata_test.go:27 (SomeType.wrapPtr) ---\n"+
 			"caused by checking function name\n"+
-			" --- at terror/testdata_test.go:24 (SomeType.wrap) ---\n"+
+			" --- at github.com/Tanium-OSS/terror/testdata_test.go:24 (SomeType.wrap) ---\n"+
 			"caused by some error",
 		fmt.Sprintf("%+v", err),
 	)
@@ -156,7 +136,7 @@ func TestNew(t *testing.T) {
 	assert.Equal(t,
 		""+
 			"adjusting 12 things\n"+
-			" --- at terror/testdata_test.go:30 (newErr) ---",
+			" --- at github.com/Tanium-OSS/terror/testdata_test.go:30 (newErr) ---",
 		fmt.Sprintf("%+v", err),
 	)
 }
@@ -177,7 +157,7 @@ func TestGo113(t *testing.T) {
 	err = fmt.Errorf("destroyed formatting: %w", err)
 	const preFormattedErrorString = "" +
 		"destroyed formatting: wrapped in terror\n" +
-		" --- at terror/testdata_test.go:18 (wrapMessage) ---\n" +
+		" --- at github.com/Tanium-OSS/terror/testdata_test.go:18 (wrapMessage) ---\n" +
 		"caused by some error"
 	assert.Equal(t, preFormattedErrorString, err.Error())
 	assert.Equal(t, preFormattedErrorString, fmt.Sprintf("%s", err))
@@ -195,7 +175,7 @@ func TestGo113(t *testing.T) {
 	assert.Equal(t,
 		""+
 			"wrapped in terror\n"+
-			" --- at terror/testdata_test.go:18 (wrapMessage) ---\n"+
+			" --- at github.com/Tanium-OSS/terror/testdata_test.go:18 (wrapMessage) ---\n"+
 			"caused by some error",
 		fmt.Sprintf("%+v", ourError),
 	)
@@ -232,8 +212,8 @@ func TestCode(t *testing.T) {
 	assert.Equal(t, 0, GetCode(WrapWithCode(NewWithCode(1, "foo"), 0, "bar")))
 	assert.Equal(t, 0, GetCode(WrapWithCode(WrapWithCode(errSentinel, 2, "foo"), 0, "bar")))
 
-	// multierr picks the first error in the chain
-	assert.Equal(t, 11, GetCode(multierr.Combine(NewWithCode(11, "foo"), NewWithCode(12, "foo"))))
+	// a multi-error picks the first error in the chain
+	assert.Equal(t, 11, GetCode(Combine(NewWithCode(11, "foo"), NewWithCode(12, "foo"))))
 }
 
 func TestPanicStacks(t *testing.T) {
@@ -242,9 +222,9 @@ func TestPanicStacks(t *testing.T) {
 	assert.Equal(t,
 		""+
 			"panic\n"+
-			" --- at terror/testdata_test.go:34 (panicError.func1) ---\n"+
+			" --- at github.com/Tanium-OSS/terror/testdata_test.go:34 (panicError (closure #1)) ---\n"+
 			"caused by error\n"+
-			" --- at terror/testdata_test.go:36 (panicError) ---",
+			" --- at github.com/Tanium-OSS/terror/testdata_test.go:36 (panicError) ---",
 		fmt.Sprintf("%+v", err),
 	)
 }
@@ -268,3 +248,196 @@ func TestRootError(t *testing.T) {
 	assert.NoError(t, err)
 	assert.True(t, r.MatchString(rl.Location().String()))
 }
+
+func walkTree(depth int) error {
+	if depth == 0 {
+		return New("leaf %d missing", 7)
+	}
+	return Wrap(walkTree(depth-1), "walk")
+}
+
+func TestRepeatedLayers(t *testing.T) {
+	err := walkTree(3)
+	assert.Equal(t, "walk (repeated 3 times): leaf 7 missing", err.Error())
+	assert.Regexp(t, ""+
+		`^walk\n`+
+		` --- at github.com/Tanium-OSS/terror/errors_test.go:\d+ \(walkTree\) --- \(repeated 3 times\)\n`+
+		`caused by leaf 7 missing\n`+
+		` --- at github.com/Tanium-OSS/terror/errors_test.go:\d+ \(walkTree\) ---$`,
+		fmt.Sprintf("%+v", err))
+
+	// Layers are only collapsed when they are consecutive.
+	err = Wrap(Wrap(Annotate(walkTree(2)), "walk"), "outer")
+	assert.Equal(t, "outer: walk (repeated 3 times): leaf 7 missing", err.Error())
+	assert.Regexp(t, ""+
+		`^outer\n`+
+		` --- at .* \(TestRepeatedLayers\) ---\n`+
+		`caused by walk\n`+
+		` --- at .* \(TestRepeatedLayers\) ---\n`+
+		` --- at .* \(TestRepeatedLayers\) ---\n`+
+		`caused by walk\n`+
+		` --- at .* \(walkTree\) --- \(repeated 2 times\)\n`+
+		`caused by leaf 7 missing\n`+
+		` --- at .* \(walkTree\) ---$`,
+		fmt.Sprintf("%+v", err))
+}
+
+func TestRepeatedLayers_Retry(t *testing.T) {
+	var err error = errSentinel
+	for attempt := 1; attempt <= 3; attempt++ {
+		err = Wrap(err, "attempt %d", attempt)
+	}
+	// The messages differ, so each is kept, but the locations are collapsed.
+	assert.Equal(t, "attempt 3: attempt 2: attempt 1: some error", err.Error())
+	assert.Regexp(t, ""+
+		`^attempt 3\n`+
+		` --- at .* \(TestRepeatedLayers_Retry\) --- \(repeated 3 times\)\n`+
+		`caused by some error$`,
+		fmt.Sprintf("%+v", err))
+}
+
+func TestPassthrough(t *testing.T) {
+	assert.Equal(t, io.EOF, Wrap(io.EOF, "reading"))
+	assert.Equal(t, io.EOF, Annotate(io.EOF))
+	assert.Equal(t, io.EOF, WrapWithCode(io.EOF, 7, "reading"))
+	err := io.EOF
+	WrapInto(&err, "reading")
+	assert.Equal(t, io.EOF, err)
+
+	// Only the sentinel itself is passed through.
+	wrapped := fmt.Errorf("reading: %w", io.EOF)
+	assert.IsType(t, TError{}, Wrap(wrapped, "outer"))
+	assert.IsType(t, TError{}, Wrap(io.ErrUnexpectedEOF, "reading"))
+}
+
+func TestPassthrough_Config(t *testing.T) {
+	defer SetConfig(Config{})
+
+	var passed []string
+	SetConfig(Config{
+		Passthrough: []error{context.Canceled},
+		OnPassthrough: func(err error, loc Location) {
+			passed = append(passed, err.Error()+" at "+loc.Function)
+		},
+	})
+	assert.Equal(t, context.Canceled, Wrap(context.Canceled, "waiting"))
+	assert.IsType(t, TError{}, Wrap(io.EOF, "reading"))
+	assert.Equal(t, []string{"context canceled at TestPassthrough_Config"}, passed)
+
+	// An empty list wraps every error.
+	SetConfig(Config{Passthrough: []error{}})
+	assert.IsType(t, TError{}, Wrap(io.EOF, "reading"))
+
+	// Wrappers can have their own list.
+	w := NewWrapper(WithPassthrough(errSentinel))
+	assert.Equal(t, errSentinel, w.Wrap(errSentinel, "passed"))
+	assert.IsType(t, TError{}, w.Annotate(io.EOF))
+}
+
+func TestOnCreate(t *testing.T) {
+	defer SetConfig(Config{})
+	var created []string
+	SetConfig(Config{
+		OnCreate: func(err TError, code int, wrapped bool) {
+			created = append(created, fmt.Sprintf("%s %d %t", err.Message(), code, wrapped))
+		},
+	})
+	err := New("new")
+	err = WrapWithCode(err, 500, "wrap with code")
+	err = Annotate(err)
+	err = errTestKind.Wrap(Wrap(err, "wrap"), "kind")
+	NewWrapper().NewWithCode(7, "wrapper")
+	errTestKind.New("kind new")
+	_ = WrapSkip(0, NewSkip(0, "new skip"), "wrap skip")
+	assert.Equal(t, []string{
+		"new 0 false",
+		"wrap with code 500 true",
+		"wrap 0 true",
+		"kind 404 true",
+		"wrapper 7 false",
+		"kind new 404 false",
+		"new skip 0 false",
+		"wrap skip 0 true",
+	}, created)
+}
+
+func TestWrapUnless(t *testing.T) {
+	assert.Nil(t, WrapUnless(nil, []error{errSentinel}, "nothing"))
+	assert.Equal(t, errSentinel, WrapUnless(errSentinel, []error{errSentinel}, "passed"))
+	assert.Equal(t, io.EOF, WrapUnless(io.EOF, []error{errSentinel}, "passed"))
+
+	err := WrapUnless(io.ErrUnexpectedEOF, []error{errSentinel}, "reading %d", 1)
+	assert.EqualError(t, err, "reading 1: unexpected EOF")
+	assert.Equal(t, "TestWrapUnless", err.(TError).Location().Function) //nolint:errorlint
+}
+
+func TestCodeTarget(t *testing.T) {
+	err := Wrap(WrapWithCode(tryButFail(), 404, "not found"), "outer")
+	assert.ErrorIs(t, err, CodeTarget(404))
+	assert.NotErrorIs(t, err, CodeTarget(500))
+	assert.ErrorIs(t, err, errSentinel)
+
+	// Every code in the chain matches, not only the one returned by GetCode.
+	err = WrapWithCode(err, 500, "failed")
+	assert.Equal(t, 500, GetCode(err))
+	assert.ErrorIs(t, err, CodeTarget(404))
+	assert.ErrorIs(t, err, CodeTarget(500))
+
+	kind := NewKind("conflict", 409)
+	assert.ErrorIs(t, kind.Wrap(errSentinel, "updating"), CodeTarget(409))
+	assert.ErrorIs(t, Append(errSentinel, NewWithCode(418, "teapot")), CodeTarget(418))
+	assert.NotErrorIs(t, errSentinel, CodeTarget(0))
+	assert.EqualError(t, CodeTarget(404), "error code 404")
+}
+
+// uncomparableError cannot be compared with ==.
+type uncomparableError []string
+
+func (e uncomparableError) Error() string { return e[0] }
+
+func TestUncomparableBase(t *testing.T) {
+	base := uncomparableError{"uncomparable"}
+	err := Wrap(base, "wrapped")
+	other := Wrap(uncomparableError{"uncomparable"}, "wrapped")
+	target := err
+
+	assert.NotPanics(t, func() {
+		assert.ErrorIs(t, err, target)
+		assert.ErrorIs(t, Wrap(err, "outer"), target)
+		assert.NotErrorIs(t, other, target)
+		assert.True(t, err == target) //nolint:errorlint
+		assert.False(t, err == other) //nolint:errorlint
+	})
+
+	assert.EqualError(t, err, "wrapped: uncomparable")
+	assert.Equal(t, base, errors.Unwrap(err))
+	var unwrapped uncomparableError
+	assert.True(t, errors.As(err, &unwrapped))
+	assert.Regexp(t, `^wrapped\n --- at .* \(TestUncomparableBase\) ---\ncaused by uncomparable$`, fmt.Sprintf("%+v", err))
+
+	for _, wrapped := range []error{
+		WithSeverity(base, SeverityInfo),
+		WithHint(base, "hint"),
+		WithDetail(base, "detail"),
+		WithUserMessage(base, "message"),
+	} {
+		assert.NotPanics(t, func() { assert.ErrorIs(t, Wrap(wrapped, "outer"), wrapped) })
+		assert.Equal(t, base, errors.Unwrap(wrapped))
+		assert.Equal(t, "uncomparable", wrapped.Error())
+	}
+}
+
+func BenchmarkError(b *testing.B) {
+	err := Wrap(Wrap(New("root %d", 1), "middle"), "outer")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = err.Error()
+	}
+}
+
+func BenchmarkWrap(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = Wrap(errSentinel, "loading %s %d", "config.json", i)
+	}
+}
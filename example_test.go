@@ -30,16 +30,16 @@ func Example() {
 	fmt.Printf("Error code: %d\n", terror.GetCode(err))
 	// Output:
 	// initializing system
-	//  --- at terror/example_test.go:21 (InitializeSystem) ---
+	//  --- at github.com/Tanium-OSS/terror/example_test.go:21 (InitializeSystem) ---
 	// caused by loading config
-	//  --- at terror/example_test.go:14 (ReadConfig) ---
+	//  --- at github.com/Tanium-OSS/terror/example_test.go:14 (ReadConfig) ---
 	// caused by open oops where mah bucket?: no such file or directory
 	// initializing system: loading config: open oops where mah bucket?: no such file or directory
 	// initializing system: loading config: open oops where mah bucket?: no such file or directory
 	// initializing system
-	//  --- at terror/example_test.go:21 (InitializeSystem) ---
+	//  --- at github.com/Tanium-OSS/terror/example_test.go:21 (InitializeSystem) ---
 	// caused by loading config
-	//  --- at terror/example_test.go:14 (ReadConfig) ---
+	//  --- at github.com/Tanium-OSS/terror/example_test.go:14 (ReadConfig) ---
 	// caused by open oops where mah bucket?: no such file or directory
 	// Error code: 123
 }
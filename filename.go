@@ -0,0 +1,211 @@
+package terror
+
+import (
+	"path"
+	"path/filepath"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode"
+)
+
+// FullFileName is a Config.CleanFileName strategy that passes file names through unchanged. File
+// names are then absolute paths on the build machine, unless the binary was built with -trimpath.
+func FullFileName(filename string) string {
+	return filename
+}
+
+// BaseFileName is a Config.CleanFileName strategy that keeps only the last element of the file
+// name.
+func BaseFileName(filename string) string {
+	return path.Base(filepath.ToSlash(filename))
+}
+
+// ModuleRelativeFileName is a Config.CleanFileName strategy that rewrites file names to be relative
+// to the module path, e.g. "github.com/foo/bar/pkg/file.go", regardless of where or how the binary
+// was built:
+//
+//   - Files built with -trimpath are already named this way, save for the version of dependencies.
+//   - Files in the module cache or a vendor directory are named after the dependency, using the
+//     module list recorded in the binary's build info.
+//   - Files of the main module, and of dependencies replaced by local directories, are named after
+//     the module whose directory contains them. The directory of the main module is learned from
+//     the first location captured in one of its packages, so until then, and for any other file,
+//     the file name is passed through unchanged.
+//
+// Results are cached by file name.
+func ModuleRelativeFileName(filename string) string {
+	if name, ok := relativeNames.Load(filename); ok {
+		return name.(string)
+	}
+	name, ok := moduleRelativeName(filename)
+	if ok {
+		relativeNames.Store(filename, name)
+	}
+	return name
+}
+
+// relativeNames caches the results of ModuleRelativeFileName by file name.
+var relativeNames sync.Map
+
+// moduleRelativeName implements ModuleRelativeFileName. It reports whether the name can be cached,
+// which is not the case of names that may be resolved once the main module's directory is known.
+func moduleRelativeName(filename string) (string, bool) {
+	slashed := filepath.ToSlash(filename)
+	info := loadBuildModules()
+	for _, dep := range info.deps {
+		if i := strings.Index(slashed, dep.dir); i == 0 || i > 0 && slashed[i-1] == '/' {
+			return dep.path + "/" + slashed[i+len(dep.dir):], true
+		}
+	}
+	if i := strings.LastIndex(slashed, "/vendor/"); i >= 0 {
+		return slashed[i+len("/vendor/"):], true
+	}
+	if info.trimpath || !filepath.IsAbs(filename) {
+		return slashed, true
+	}
+	local := mainModule.Load()
+	if local == nil {
+		return filename, false
+	}
+	for _, dep := range *local {
+		if strings.HasPrefix(slashed, dep.dir) {
+			return dep.path + "/" + slashed[len(dep.dir):], true
+		}
+	}
+	return filename, true
+}
+
+// buildModule is a dependency listed in the build info.
+type buildModule struct {
+	// dir is how the root directory of the module appears in file names: its path and version,
+	// followed by a slash.
+	dir string
+	// path is the module path that files in dir are attributed to.
+	path string
+}
+
+type buildModules struct {
+	deps     []buildModule
+	trimpath bool
+	// mainPath is the path of the main module, and mainPackage that of the main package.
+	mainPath    string
+	mainPackage string
+	// replaced lists the dependencies replaced by local directories, whose dir is the path of the
+	// directory as written in go.mod, which may be relative to the main module's directory.
+	replaced []buildModule
+}
+
+var buildInfo struct {
+	once    sync.Once
+	modules buildModules
+}
+
+// loadBuildModules reads the dependencies of this binary from its build info.
+func loadBuildModules() buildModules {
+	buildInfo.once.Do(func() {
+		info, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+		mods := &buildInfo.modules
+		mods.mainPath, mods.mainPackage = info.Main.Path, info.Path
+		for _, setting := range info.Settings {
+			if setting.Key == "-trimpath" {
+				mods.trimpath, _ = strconv.ParseBool(setting.Value)
+			}
+		}
+		for _, dep := range info.Deps {
+			source := dep
+			if dep.Replace != nil {
+				source = dep.Replace
+			}
+			if source.Version == "" {
+				mods.replaced = append(mods.replaced, buildModule{filepath.ToSlash(source.Path), dep.Path})
+				continue
+			}
+			dir := source.Path + "@" + source.Version + "/"
+			mods.deps = append(mods.deps, buildModule{dir, dep.Path})
+			// The module cache escapes paths on disk but -trimpath does not.
+			if escaped := escapeModulePath(source.Path); escaped != source.Path {
+				mods.deps = append(mods.deps, buildModule{escaped + "@" + source.Version + "/", dep.Path})
+			}
+		}
+	})
+	return buildInfo.modules
+}
+
+// escapeModulePath escapes a module path the way the module cache does on disk, replacing each
+// upper-case letter by an exclamation mark followed by the letter's lower-case equivalent.
+func escapeModulePath(modPath string) string {
+	var b strings.Builder
+	for _, r := range modPath {
+		if unicode.IsUpper(r) {
+			b.WriteByte('!')
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// mainModule holds the local module directories once the main module's directory is known: those
+// of dependencies replaced by local directories, followed by that of the main module.
+var mainModule atomic.Pointer[[]buildModule]
+
+// learnMainModule records the directory of the main module, if it isn't known yet and the function
+// of a captured frame, found in file, belongs to one of its packages.
+func learnMainModule(file, function string) {
+	if mainModule.Load() != nil {
+		return
+	}
+	info := loadBuildModules()
+	if info.mainPath == "" || info.trimpath {
+		return
+	}
+	root := moduleRoot(file, function, info.mainPath, info.mainPackage)
+	if root == "" {
+		return
+	}
+	var local []buildModule
+	for _, dep := range info.replaced {
+		dir := dep.dir
+		if !filepath.IsAbs(filepath.FromSlash(dir)) {
+			dir = path.Join(root, dir)
+		}
+		local = append(local, buildModule{strings.TrimSuffix(dir, "/") + "/", dep.path})
+	}
+	local = append(local, buildModule{root + "/", info.mainPath})
+	mainModule.CompareAndSwap(nil, &local)
+}
+
+// moduleRoot returns the directory of the module at modPath, given the file that function was
+// found in, or "" if the function's package isn't in the module. Functions of package main are
+// in mainPackage.
+func moduleRoot(file, function, modPath, mainPackage string) string {
+	pkg := funcPackage(function)
+	if pkg == "main" {
+		pkg = mainPackage
+	}
+	pkg = strings.TrimSuffix(pkg, "_test")
+	rel := strings.TrimPrefix(pkg, modPath)
+	if rel != "" && (rel == pkg || rel[0] != '/') {
+		return ""
+	}
+	dir := path.Dir(file)
+	if !strings.HasSuffix(dir, rel) {
+		return ""
+	}
+	return dir[:len(dir)-len(rel)]
+}
+
+// funcPackage returns the import path of the package of a function, as named by runtime.Frame.
+func funcPackage(function string) string {
+	slash := strings.LastIndex(function, "/") + 1
+	if dot := strings.Index(function[slash:], "."); dot >= 0 {
+		return function[:slash+dot]
+	}
+	return function
+}
@@ -0,0 +1,75 @@
+package terror
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBaseFileName(t *testing.T) {
+	assert.Equal(t, "file.go", BaseFileName("/home/gopher/src/pkg/file.go"))
+	assert.Equal(t, "file.go", BaseFileName("github.com/foo/bar/file.go"))
+	assert.Equal(t, "file.go", BaseFileName("file.go"))
+}
+
+func TestModuleRelativeFileName(t *testing.T) {
+	for _, tc := range []struct{ filename, want string }{
+		// Main module, with and without -trimpath.
+		{capture(0).File, "github.com/Tanium-OSS/terror/filename_test.go"},
+		{"github.com/Tanium-OSS/terror/errors.go", "github.com/Tanium-OSS/terror/errors.go"},
+		// Dependencies, in the module cache and with -trimpath.
+		{"/home/gopher/go/pkg/mod/github.com/stretchr/testify@v1.8.0/assert/assertions.go", "github.com/stretchr/testify/assert/assertions.go"},
+		{"github.com/stretchr/testify@v1.8.0/assert/assertions.go", "github.com/stretchr/testify/assert/assertions.go"},
+		// Vendored dependencies.
+		{"/home/gopher/src/app/vendor/example.com/dep/file.go", "example.com/dep/file.go"},
+		// Files of the main module need not exist, as its directory is learned from captured frames.
+		{filepath.Join(filepath.Dir(capture(0).File), "no", "such", "file.go"), "github.com/Tanium-OSS/terror/no/such/file.go"},
+		// Anything else is left alone.
+		{"/no/such/module/file.go", "/no/such/module/file.go"},
+	} {
+		assert.Equal(t, tc.want, ModuleRelativeFileName(tc.filename), tc.filename)
+	}
+}
+
+func TestModuleRelativeFileName_Trimpath(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a binary")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not found")
+	}
+	const want = "" +
+		"located\n" +
+		" --- at github.com/Tanium-OSS/terror/testdata/trimpath/main.go:12 (main) ---\n"
+	for _, args := range [][]string{
+		{"run", "./testdata/trimpath"},
+		{"run", "-trimpath", "./testdata/trimpath"},
+	} {
+		out, err := exec.Command(goTool, args...).CombinedOutput()
+		require.NoError(t, err, string(out))
+		assert.Equal(t, want, string(out), args)
+	}
+}
+
+func TestEscapeModulePath(t *testing.T) {
+	assert.Equal(t, "github.com/!tanium-!o!s!s/terror", escapeModulePath("github.com/Tanium-OSS/terror"))
+}
+
+func TestModuleRoot(t *testing.T) {
+	const mod = "example.com/m"
+	for _, tc := range []struct{ file, function, want string }{
+		{"/src/m/file.go", "example.com/m.F", "/src/m"},
+		{"/src/m/pkg/sub/file.go", "example.com/m/pkg/sub.(*T).Method", "/src/m"},
+		{"/src/m/file_test.go", "example.com/m_test.TestF", "/src/m"},
+		{"/src/m/cmd/app/main.go", "main.main", "/src/m"},
+		{"/src/m/pkg/file.go", "example.com/m/other.F", ""},
+		{"/src/mod/file.go", "example.com/mod.F", ""},
+		{"/go/pkg/mod/example.com/dep@v1.0.0/file.go", "example.com/dep.F", ""},
+	} {
+		assert.Equal(t, tc.want, moduleRoot(tc.file, tc.function, mod, "example.com/m/cmd/app"), tc.function)
+	}
+}
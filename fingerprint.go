@@ -0,0 +1,84 @@
+package terror
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"strconv"
+)
+
+// FingerprintOption configures how Fingerprint identifies an error chain.
+type FingerprintOption func(*fingerprintOptions)
+
+type fingerprintOptions struct {
+	line        bool
+	foreignText bool
+}
+
+// FingerprintLine includes the line number of each location in the fingerprint. By default only
+// the file and function are used so that fingerprints survive unrelated edits to the file.
+func FingerprintLine() FingerprintOption {
+	return func(o *fingerprintOptions) { o.line = true }
+}
+
+// FingerprintForeignText includes the text of the innermost error in the fingerprint if that error
+// was not created by this package. By default only its type is used, since the text of foreign
+// errors typically embeds variable data such as file names.
+func FingerprintForeignText() FingerprintOption {
+	return func(o *fingerprintOptions) { o.foreignText = true }
+}
+
+// Fingerprint returns a stable hash identifying the shape of an error chain, suitable for grouping
+// errors and deduplicating alerts. Two errors have the same fingerprint if they were wrapped at the
+// same functions and files with the same format templates and codes, regardless of the arguments
+// that were formatted into their messages. If err is nil, Fingerprint returns "".
+func Fingerprint(err error, opts ...FingerprintOption) string {
+	if err == nil {
+		return ""
+	}
+	var o fingerprintOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	h := fnv.New64a()
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		switch e := e.(type) { //nolint:errorlint
+		case TError:
+			writeFields(h, "T", e.format, e.loc.File, e.loc.Function)
+			if o.line {
+				writeFields(h, strconv.Itoa(e.loc.Line))
+			}
+		case codeError:
+			writeFields(h, "C", strconv.Itoa(e.code))
+		case kindError:
+			writeFields(h, "K", strconv.Itoa(e.kind.code), e.kind.msg)
+		case *Kind:
+			writeFields(h, "K", strconv.Itoa(e.code), e.msg)
+		case Const:
+			writeFields(h, "S", string(e))
+		case noter:
+			// Notes such as severities do not change the shape of the chain.
+		case interface{ Unwrap() []error }:
+			writeFields(h, "M", fmt.Sprintf("%T", e))
+			for _, err := range e.Unwrap() {
+				writeFields(h, Fingerprint(err, opts...))
+			}
+		default:
+			writeFields(h, "F", fmt.Sprintf("%T", e))
+			if o.foreignText && errors.Unwrap(e) == nil {
+				writeFields(h, e.Error())
+			}
+		}
+	}
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// writeFields writes each field to w followed by a NUL separator so that adjacent fields cannot
+// run together.
+func writeFields(w io.Writer, fields ...string) {
+	for _, field := range fields {
+		io.WriteString(w, field)
+		w.Write([]byte{0})
+	}
+}
@@ -0,0 +1,56 @@
+package terror
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readConfigFile(name string) error {
+	_, err := os.ReadFile(name)
+	return Wrap(err, "loading config %s", name)
+}
+
+func TestFingerprint(t *testing.T) {
+	assert.Equal(t, "", Fingerprint(nil))
+
+	a := readConfigFile("a.json")
+	b := readConfigFile("b.json")
+	assert.NotEqual(t, a.Error(), b.Error())
+	assert.Equal(t, Fingerprint(a), Fingerprint(b))
+	assert.Len(t, Fingerprint(a), 16)
+
+	// Anything in the chain other than args and foreign text is significant.
+	assert.NotEqual(t, Fingerprint(a), Fingerprint(Wrap(a, "")))
+	assert.NotEqual(t, Fingerprint(a), Fingerprint(tryButFail()))
+	assert.NotEqual(t, Fingerprint(New("a %d", 1)), Fingerprint(New("b %d", 1)))
+	assert.NotEqual(t, Fingerprint(WrapWithCode(errSentinel, 1, "")), Fingerprint(WrapWithCode(errSentinel, 2, "")))
+	assert.NotEqual(t, Fingerprint(errTestKind.New("")), Fingerprint(NewKind("other", 404).New("")))
+	assert.NotEqual(t, Fingerprint(Wrap(Const("a"), "")), Fingerprint(Wrap(Const("b"), "")))
+	assert.NotEqual(t, Fingerprint(Wrap(errSentinel, "")), Fingerprint(Wrap(SomeCustomErrorWrapper{errSentinel}, "")))
+}
+
+func TestFingerprint_Options(t *testing.T) {
+	errs := make([]error, 2)
+	for i := range errs {
+		errs[i] = Wrap(fmt.Errorf("attempt %d", i), "")
+	}
+	assert.Equal(t, Fingerprint(errs[0]), Fingerprint(errs[1]))
+	assert.Equal(t, Fingerprint(errs[0], FingerprintLine()), Fingerprint(errs[1], FingerprintLine()))
+	assert.NotEqual(t, Fingerprint(errs[0], FingerprintForeignText()), Fingerprint(errs[1], FingerprintForeignText()))
+
+	first := Wrap(errSentinel, "")
+	second := Wrap(errSentinel, "")
+	assert.Equal(t, Fingerprint(first), Fingerprint(second))
+	assert.NotEqual(t, Fingerprint(first, FingerprintLine()), Fingerprint(second, FingerprintLine()))
+
+	// Only the innermost foreign text is used, since wrapping errors embed the text of their base.
+	wrapped := func(name string) error { return fmt.Errorf("opening %s: %w", name, errors.New("denied")) }
+	assert.Equal(t,
+		Fingerprint(Wrap(wrapped("a"), ""), FingerprintForeignText()),
+		Fingerprint(Wrap(wrapped("b"), ""), FingerprintForeignText()),
+	)
+}
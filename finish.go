@@ -0,0 +1,39 @@
+package terror
+
+// Committer is a transaction that is finished by either committing or rolling it back, such as a
+// *sql.Tx.
+type Committer interface {
+	Commit() error
+	Rollback() error
+}
+
+// Finish commits tx if *pErr is nil, and otherwise rolls it back, appending any failure to *pErr.
+// Failures to commit or roll back are wrapped with the location of the return from the calling
+// function. The original error remains in the chain, so errors.Is and errors.As continue to work on
+// it. If the calling function panics, tx is rolled back and the panic continues; as a panicking
+// function returns no result, a failure to roll back is then lost. The intended use is to defer
+// Finish with a named return variable:
+//
+//	func transfer(db *sql.DB) (err error) {
+//		tx, err := db.Begin()
+//		if err != nil {
+//			return terror.Wrap(err, "beginning transfer")
+//		}
+//		defer terror.Finish(&err, tx)
+//		...
+//	}
+func Finish(pErr *error, tx Committer) {
+	if r := recover(); r != nil {
+		_ = tx.Rollback()
+		panic(r)
+	}
+	if *pErr != nil {
+		if err := tx.Rollback(); err != nil {
+			appendInto(pErr, std.newError(err, "roll back transaction", nil, 1), 1)
+		}
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		*pErr = std.newError(err, "commit transaction", nil, 1)
+	}
+}
@@ -0,0 +1,69 @@
+package terror
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTx records how it was finished.
+type fakeTx struct {
+	commitErr, rollbackErr error
+	committed, rolledBack  int
+}
+
+func (tx *fakeTx) Commit() error {
+	tx.committed++
+	return tx.commitErr
+}
+
+func (tx *fakeTx) Rollback() error {
+	tx.rolledBack++
+	return tx.rollbackErr
+}
+
+func inTransaction(tx Committer, fn func() error) (err error) {
+	defer Finish(&err, tx)
+	return fn()
+}
+
+func TestFinish_Commit(t *testing.T) {
+	tx := &fakeTx{}
+	require.NoError(t, inTransaction(tx, func() error { return nil }))
+	assert.Equal(t, 1, tx.committed)
+	assert.Equal(t, 0, tx.rolledBack)
+
+	tx = &fakeTx{commitErr: errSentinel}
+	err := inTransaction(tx, func() error { return nil })
+	assert.EqualError(t, err, "commit transaction: some error")
+	assert.ErrorIs(t, err, errSentinel)
+	assert.Equal(t, "inTransaction", err.(TError).Location().Function)
+	assert.Equal(t, 1, tx.committed)
+	assert.Equal(t, 0, tx.rolledBack)
+}
+
+func TestFinish_Rollback(t *testing.T) {
+	tx := &fakeTx{}
+	err := inTransaction(tx, func() error { return errSentinel })
+	assert.Equal(t, errSentinel, err)
+	assert.Equal(t, 0, tx.committed)
+	assert.Equal(t, 1, tx.rolledBack)
+
+	tx = &fakeTx{rollbackErr: errors.New("connection lost")}
+	err = inTransaction(tx, func() error { return Wrap(errSentinel, "transferring") })
+	assert.EqualError(t, err, "transferring: some error; roll back transaction: connection lost")
+	assert.ErrorIs(t, err, errSentinel)
+	assert.Equal(t, 0, tx.committed)
+	assert.Equal(t, 1, tx.rolledBack)
+}
+
+func TestFinish_Panic(t *testing.T) {
+	tx := &fakeTx{rollbackErr: errors.New("connection lost")}
+	assert.PanicsWithValue(t, "oops", func() {
+		_ = inTransaction(tx, func() error { panic("oops") })
+	})
+	assert.Equal(t, 0, tx.committed)
+	assert.Equal(t, 1, tx.rolledBack)
+}
@@ -0,0 +1,128 @@
+package terror
+
+import (
+	"strings"
+)
+
+// cleanFuncName removes the package path from a function name as reported by
+// the runtime and renders closures, generics and method values readably:
+//
+//	github.com/foo/bar/package.FuncName                  --> FuncName
+//	github.com/foo/bar/package.Receiver.MethodName       --> Receiver.MethodName
+//	github.com/foo/bar/package.(*PtrReceiver).MethodName --> PtrReceiver.MethodName
+//	github.com/foo/bar/package.(*Cache[...]).Get         --> Cache[T].Get
+//	github.com/foo/bar/package.Outer.func2               --> Outer (closure #2)
+//	github.com/foo/bar/package.Outer.func2.1             --> Outer (closure #2.1)
+//
+// Closures are attributed to their enclosing function and numbered the same way
+// regardless of the Go version that named them. The runtime doesn't report type
+// arguments, so generic functions and types are rendered with a placeholder.
+func cleanFuncName(longName string) string {
+	parts := splitFuncName(trimPackagePath(longName))
+	if len(parts) > 2 && parts[0] == "glob" && parts[1] == "" {
+		// Before go1.22, closures in package variable initializers were named
+		// "glob..funcN"; they're now attributed to "init".
+		parts = append([]string{"init"}, parts[2:]...)
+	}
+	var b strings.Builder
+	var closures []string
+	for i, part := range parts {
+		part = trimSyntheticSuffixes(part)
+		if i > 0 {
+			if index, ok := closureIndex(part, len(closures) > 0); ok {
+				closures = append(closures, index)
+				continue
+			}
+			b.WriteByte('.')
+		}
+		b.WriteString(cleanTypeName(part))
+	}
+	if len(closures) > 0 {
+		b.WriteString(" (closure #")
+		b.WriteString(strings.Join(closures, "."))
+		b.WriteString(")")
+	}
+	return b.String()
+}
+
+// trimPackagePath removes the package path, which ends at the first dot after
+// the last slash. Dots in the last element of the path are escaped as "%2e" by
+// the linker, and slashes may appear only in type arguments after it.
+func trimPackagePath(name string) string {
+	end := strings.IndexByte(name, '[')
+	if end < 0 {
+		end = len(name)
+	}
+	name = name[strings.LastIndexByte(name[:end], '/')+1:]
+	return name[strings.IndexByte(name, '.')+1:]
+}
+
+// splitFuncName splits name on the dots that are not enclosed in parentheses or
+// brackets.
+func splitFuncName(name string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i := 0; i < len(name); i++ {
+		switch name[i] {
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		case '.':
+			if depth == 0 {
+				parts = append(parts, name[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, name[start:])
+}
+
+// trimSyntheticSuffixes removes the suffixes of functions that the compiler
+// generates for method values ("-fm") and for the bodies of range-over-func
+// loops ("-rangeN"), which are considered part of the enclosing function.
+func trimSyntheticSuffixes(part string) string {
+	part = strings.TrimSuffix(part, "-fm")
+	for {
+		i := strings.LastIndex(part, "-range")
+		if i < 0 || !isDigits(part[i+len("-range"):]) {
+			return part
+		}
+		part = part[:i]
+	}
+}
+
+// closureIndex returns the index of a closure from an element of its name:
+// "funcN" or, before go1.22, "N" for closures nested in another closure.
+// Wrappers generated for go and defer statements are numbered like closures.
+func closureIndex(part string, nested bool) (string, bool) {
+	for _, prefix := range []string{"func", "gowrap", "deferwrap"} {
+		if strings.HasPrefix(part, prefix) && isDigits(part[len(prefix):]) {
+			return part[len(prefix):], true
+		}
+	}
+	return part, nested && isDigits(part)
+}
+
+// cleanTypeName removes the pointer and parentheses from a method receiver and
+// replaces type arguments with a placeholder.
+func cleanTypeName(part string) string {
+	if strings.HasPrefix(part, "(*") && strings.HasSuffix(part, ")") {
+		part = part[2 : len(part)-1]
+	}
+	if open := strings.IndexByte(part, '['); open >= 0 {
+		if end := strings.LastIndexByte(part, ']'); end > open {
+			part = part[:open] + "[T]" + part[end+1:]
+		}
+	}
+	return part
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
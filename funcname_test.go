@@ -0,0 +1,68 @@
+package terror
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCleanFuncName(t *testing.T) {
+	for _, tc := range []struct{ name, want string }{
+		{"", ""},
+		{"main.main", "main"},
+		{"github.com/foo/bar/pkg.FuncName", "FuncName"},
+		{"github.com/foo/bar/pkg.Receiver.MethodName", "Receiver.MethodName"},
+		{"github.com/foo/bar/pkg.(*PtrReceiver).MethodName", "PtrReceiver.MethodName"},
+		{"gopkg.in/yaml%2ev3.Marshal", "Marshal"},
+		{"pkg.init.0", "init.0"},
+		{"pkg.init.0.func1", "init.0 (closure #1)"},
+
+		// Closures, go1.21 and earlier.
+		{"github.com/foo/bar/pkg.Outer.func2", "Outer (closure #2)"},
+		{"github.com/foo/bar/pkg.Outer.func2.1", "Outer (closure #2.1)"},
+		{"github.com/foo/bar/pkg.Outer.func2.1.3", "Outer (closure #2.1.3)"},
+		{"github.com/foo/bar/pkg.(*T).Method.func1", "T.Method (closure #1)"},
+		{"github.com/foo/bar/pkg.glob..func1", "init (closure #1)"},
+		{"github.com/foo/bar/pkg.T.Method-fm", "T.Method"},
+		// Closures, go1.22 and later.
+		{"github.com/foo/bar/pkg.Outer.func2.func1", "Outer (closure #2.1)"},
+		{"github.com/foo/bar/pkg.init.func1", "init (closure #1)"},
+		{"github.com/foo/bar/pkg.Outer.gowrap1", "Outer (closure #1)"},
+		{"github.com/foo/bar/pkg.Outer.deferwrap2", "Outer (closure #2)"},
+		// Range-over-func loop bodies, go1.23 and later.
+		{"github.com/foo/bar/pkg.Outer-range1", "Outer"},
+		{"github.com/foo/bar/pkg.Outer-range1-range2", "Outer"},
+		{"github.com/foo/bar/pkg.Outer-range1.func1", "Outer (closure #1)"},
+
+		// Generics, go1.18 and later.
+		{"github.com/foo/bar/pkg.Map[...]", "Map[T]"},
+		{"github.com/foo/bar/pkg.Map[...].func1", "Map[T] (closure #1)"},
+		{"github.com/foo/bar/pkg.(*Cache[...]).Get", "Cache[T].Get"},
+		{"github.com/foo/bar/pkg.Cache[...].Get", "Cache[T].Get"},
+		{"github.com/foo/bar/pkg.(*Cache[...]).Get.func1.1", "Cache[T].Get (closure #1.1)"},
+		{"github.com/foo/bar/pkg.(*Cache[...]).Get.func1.func1", "Cache[T].Get (closure #1.1)"},
+		// Shape-instantiated names from go1.18 development builds.
+		{"github.com/foo/bar/pkg.Map[go.shape.int_0]", "Map[T]"},
+		{"github.com/foo/bar/pkg.(*Cache[go.shape.*uint8_0]).Get", "Cache[T].Get"},
+		{"github.com/foo/bar/pkg.Map[github.com/foo/baz.T]", "Map[T]"},
+	} {
+		assert.Equal(t, tc.want, cleanFuncName(tc.name), tc.name)
+	}
+}
+
+type genericCache[T any] struct{}
+
+func (c *genericCache[T]) get() error {
+	return func() error { return New("miss") }()
+}
+
+func TestCleanFuncName_Runtime(t *testing.T) {
+	var c genericCache[int]
+	assert.Equal(t, "genericCache[T].get (closure #1)", RootError(c.get()).Location().Function)
+
+	nested := func() error {
+		return func() error { return New("nested") }()
+	}
+	assert.Equal(t, "TestCleanFuncName_Runtime (closure #1.1)", RootError(nested()).Location().Function)
+	assert.Equal(t, "wrapNoMessage", wrapNoMessage(errSentinel).(TError).Location().Function)
+}
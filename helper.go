@@ -0,0 +1,59 @@
+package terror
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// helpers holds the fully-qualified names of the functions marked by Helper.
+var helpers sync.Map
+
+// helpersMarked is set once any function has been marked by Helper, so that capturing locations
+// stays cheap until then.
+var helpersMarked atomic.Bool
+
+// Helper marks the calling function as a helper that wraps or creates errors on behalf of its
+// caller, like testing.T.Helper. Errors created inside a helper are located at the call site of
+// the helper instead:
+//
+//	func dbErr(err error, query string) error {
+//		terror.Helper()
+//		return terror.Wrap(err, "query %q", query)
+//	}
+//
+// Helper may be called from helpers that call other helpers, in which case the location is that
+// of the first caller that is not a helper. Helper is safe to call concurrently and is cheap to
+// call more than once.
+func Helper() {
+	var pcs [1]uintptr
+	if runtime.Callers(2, pcs[:]) == 0 {
+		return
+	}
+	frame, _ := runtime.CallersFrames(pcs[:]).Next()
+	if _, loaded := helpers.LoadOrStore(frame.Function, struct{}{}); !loaded {
+		helpersMarked.Store(true)
+	}
+}
+
+// isHelper reports whether the function named funcName was marked by Helper.
+func isHelper(funcName string) bool {
+	_, ok := helpers.Load(funcName)
+	return ok
+}
+
+// WrapSkip is like Wrap, but locates the error skip frames above the caller of WrapSkip. A skip of
+// 0 is equivalent to Wrap, including returning errors listed in Config.Passthrough unchanged. It is
+// an alternative to Helper for helpers that know how deeply they are called.
+func WrapSkip(skip int, err error, format string, args ...interface{}) error {
+	if err == nil || std.passthrough(err, nil, skip+1) {
+		return err
+	}
+	return report(std.newError(err, format, args, skip+1), 0, true)
+}
+
+// NewSkip is like New, but locates the error skip frames above the caller of NewSkip. A skip of 0
+// is equivalent to New.
+func NewSkip(skip int, format string, args ...interface{}) error {
+	return report(std.newError(nil, format, args, skip+1), 0, false)
+}
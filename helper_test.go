@@ -0,0 +1,97 @@
+package terror
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func dbErr(err error, query string) error {
+	Helper()
+	return Wrap(err, "query %q", query)
+}
+
+func repoErr(err error) error {
+	Helper()
+	return dbErr(err, "SELECT 1")
+}
+
+//go:noinline
+func notInlinedHelper(msg string) error {
+	Helper()
+	return New(msg)
+}
+
+// maybeInlinedHelper may or may not be inlined into its callers, at the discretion of the
+// compiler.
+func maybeInlinedHelper(msg string) error {
+	Helper()
+	return New(msg)
+}
+
+// notAHelper is small enough to be inlined into its callers, so its frame only exists as an
+// inlined frame.
+func notAHelper(err error) error {
+	return dbErr(err, "SELECT 2")
+}
+
+func TestHelper(t *testing.T) {
+	err := dbErr(errSentinel, "SELECT 1")
+	assert.EqualError(t, err, `query "SELECT 1": some error`)
+	assert.Equal(t, "TestHelper", err.(TError).Location().Function) //nolint:errorlint
+
+	// Nested helpers are all skipped.
+	assert.Equal(t, "TestHelper", repoErr(errSentinel).(TError).Location().Function) //nolint:errorlint
+
+	// Only functions that call Helper are skipped.
+	assert.Equal(t, "notAHelper", notAHelper(errSentinel).(TError).Location().Function) //nolint:errorlint
+
+	// Helpers are skipped for errors created by Wrappers too.
+	w := NewWrapper()
+	helper := func() error {
+		Helper()
+		return w.New("wrapped")
+	}
+	assert.Equal(t, "TestHelper", helper().(TError).Location().Function) //nolint:errorlint
+}
+
+func TestHelper_Inlining(t *testing.T) {
+	for _, err := range []error{notInlinedHelper("not inlined"), maybeInlinedHelper("maybe inlined")} {
+		located := err.(TError) //nolint:errorlint
+		assert.Equal(t, "TestHelper_Inlining", located.Location().Function, located.Message())
+		assert.Equal(t, "github.com/Tanium-OSS/terror/helper_test.go", located.Location().File)
+	}
+
+	// An inlined caller of a helper is located like any other.
+	located := notAHelper(errSentinel).(TError) //nolint:errorlint
+	assert.Equal(t, "notAHelper", located.Location().Function)
+	assert.Equal(t, 36, located.Location().Line)
+}
+
+func TestWrapSkip(t *testing.T) {
+	assert.Nil(t, WrapSkip(1, nil, "nothing"))
+
+	here := Wrap(errSentinel, "here")
+	assert.Equal(t, here.(TError).Location().Function, WrapSkip(0, errSentinel, "here").(TError).Location().Function) //nolint:errorlint
+
+	skipped := func() error { return WrapSkip(1, errSentinel, "skipped %d", 1) }()
+	assert.EqualError(t, skipped, "skipped 1: some error")
+	assert.Equal(t, "TestWrapSkip", skipped.(TError).Location().Function) //nolint:errorlint
+
+	// Sentinels pass through, as with Wrap, and are reported at the skipped location.
+	defer SetConfig(Config{})
+	var passedAt string
+	SetConfig(Config{OnPassthrough: func(_ error, loc Location) { passedAt = loc.Function }})
+	assert.Equal(t, io.EOF, WrapSkip(0, io.EOF, "reading"))
+	assert.Equal(t, io.EOF, func() error { return WrapSkip(1, io.EOF, "reading") }())
+	assert.Equal(t, "TestWrapSkip", passedAt)
+}
+
+func TestNewSkip(t *testing.T) {
+	assert.Equal(t, "TestNewSkip", NewSkip(0, "here").(TError).Location().Function) //nolint:errorlint
+
+	skipped := func() error { return NewSkip(1, "skipped %d", 1) }()
+	assert.EqualError(t, skipped, "skipped 1")
+	assert.Equal(t, "TestNewSkip", skipped.(TError).Location().Function) //nolint:errorlint
+}
@@ -0,0 +1,103 @@
+package terror
+
+import (
+	"errors"
+	"fmt"
+)
+
+// WithHint annotates err with a hint for the user on how to resolve it, such as "check that the
+// config path exists". Hints are not part of Error(), so that they can be shown separately from
+// the cause of the error; they are shown in the detailed format and returned by Hints. The format
+// and args are formatted printf style, and are redacted like those of New when the error is encoded
+// by EncodeProto. If err is nil, WithHint returns nil.
+func WithHint(err error, format string, args ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	hint := fmt.Sprintf(format, args...)
+	return hintError{box(err), hint, format, newRedaction(hint, format, args, std.redactionPolicy())}
+}
+
+// WithDetail annotates err with additional technical detail, such as the contents of a request.
+// Like hints, details are not part of Error(); they are shown in the detailed format and returned
+// by Details. The format and args are formatted printf style, and are redacted like those of New
+// when the error is encoded by EncodeProto. If err is nil, WithDetail returns nil.
+func WithDetail(err error, format string, args ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	detail := fmt.Sprintf(format, args...)
+	return detailError{box(err), detail, format, newRedaction(detail, format, args, std.redactionPolicy())}
+}
+
+type hintError struct {
+	base error
+	hint string
+	// format and redaction are what redactedHint needs.
+	format    string
+	redaction *redaction
+}
+
+func (e hintError) Unwrap() error              { return unbox(e.base) }
+func (e hintError) Error() string              { return e.base.Error() }
+func (e hintError) note() string               { return "hint: " + e.hint }
+func (e hintError) Format(f fmt.State, c rune) { formatNoted(e.note(), e.base, f, c) }
+
+// redactedHint returns the hint with unsafe arguments replaced.
+func (e hintError) redactedHint() string { return e.redaction.redact(e.hint, e.format) }
+
+type detailError struct {
+	base   error
+	detail string
+	// format and redaction are what redactedDetail needs.
+	format    string
+	redaction *redaction
+}
+
+func (e detailError) Unwrap() error              { return unbox(e.base) }
+func (e detailError) Error() string              { return e.base.Error() }
+func (e detailError) note() string               { return "detail: " + e.detail }
+func (e detailError) Format(f fmt.State, c rune) { formatNoted(e.note(), e.base, f, c) }
+
+// redactedDetail returns the detail with unsafe arguments replaced.
+func (e detailError) redactedDetail() string { return e.redaction.redact(e.detail, e.format) }
+
+// Hints returns the hints added with WithHint anywhere in the chain of err, including every error
+// combined in a multi-error, from the outermost. Duplicate hints are returned once.
+func Hints(err error) []string {
+	return collectNotes(err, func(err error) (string, bool) {
+		hint, ok := err.(hintError) //nolint:errorlint
+		return hint.hint, ok
+	})
+}
+
+// Details returns the details added with WithDetail anywhere in the chain of err, including every
+// error combined in a multi-error, from the outermost. Duplicate details are returned once.
+func Details(err error) []string {
+	return collectNotes(err, func(err error) (string, bool) {
+		detail, ok := err.(detailError) //nolint:errorlint
+		return detail.detail, ok
+	})
+}
+
+// collectNotes returns the distinct texts that get returns for the errors in the chain of err.
+func collectNotes(err error, get func(error) (string, bool)) []string {
+	var notes []string
+	seen := make(map[string]bool)
+	var walk func(err error)
+	walk = func(err error) {
+		for ; err != nil; err = errors.Unwrap(err) {
+			if note, ok := get(err); ok && !seen[note] {
+				seen[note] = true
+				notes = append(notes, note)
+			}
+			if multi, ok := err.(interface{ Unwrap() []error }); ok { //nolint:errorlint
+				for _, err := range multi.Unwrap() {
+					walk(err)
+				}
+			}
+		}
+	}
+	walk(err)
+	return notes
+}
@@ -0,0 +1,73 @@
+package terror
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ExampleWithHint() {
+	err := WithHint(New("open %s: no such file", "app.yaml"), "check that the config path exists")
+	err = Wrap(err, "loading config")
+	fmt.Println("Error:", err.Error())
+	for _, hint := range Hints(err) {
+		fmt.Println("Hint:", hint)
+	}
+	// Output:
+	// Error: loading config: open app.yaml: no such file
+	// Hint: check that the config path exists
+}
+
+func TestWithHint(t *testing.T) {
+	assert.Nil(t, WithHint(nil, "nothing"))
+	assert.Nil(t, Hints(nil))
+	assert.Nil(t, Hints(tryButFail()))
+
+	err := WithHint(Wrap(WithHint(errSentinel, "inner hint %d", 1), "wrapped"), "outer hint")
+	assert.EqualError(t, err, "wrapped: some error")
+	assert.ErrorIs(t, err, errSentinel)
+	assert.Equal(t, []string{"outer hint", "inner hint 1"}, Hints(err))
+	assert.Nil(t, Details(err))
+
+	// Hints are collected from every error combined, once each.
+	combined := Append(err, WithHint(errors.New("other"), "outer hint"))
+	assert.Equal(t, []string{"outer hint", "inner hint 1"}, Hints(combined))
+	combined = Append(WithHint(errors.New("first"), "first hint"), WithHint(errors.New("second"), "second hint"))
+	assert.Equal(t, []string{"first hint", "second hint"}, Hints(combined))
+}
+
+func TestWithDetail(t *testing.T) {
+	assert.Nil(t, WithDetail(nil, "nothing"))
+
+	err := WithHint(WithDetail(Wrap(errSentinel, "request failed"), "status %d", 503), "try again later")
+	assert.EqualError(t, err, "request failed: some error")
+	assert.Equal(t, []string{"status 503"}, Details(err))
+	assert.Equal(t, []string{"try again later"}, Hints(err))
+	assert.Equal(t, "request failed: some error", Redacted(Wrap(WithDetail(Const("some error"), "secret"), "request failed")))
+}
+
+func TestWithHint_Format(t *testing.T) {
+	err := Wrap(WithDetail(WithHint(tryButFail(), "check the input"), "input was %q", "x"), "outer")
+	assert.Regexp(t, ""+
+		`^outer\n`+
+		` --- at github.com/Tanium-OSS/terror/hint_test.go:\d+ \(TestWithHint_Format\) ---\n`+
+		` --- detail: input was "x" ---\n`+
+		` --- hint: check the input ---\n`+
+		`caused by trying something\n`+
+		` --- at github.com/Tanium-OSS/terror/testdata_test.go:13 \(tryButFail\) ---\n`+
+		`caused by some error$`,
+		fmt.Sprintf("%+v", err))
+
+	assert.Equal(t, ""+
+		" --- hint: check the input ---\n"+
+		"trying something\n"+
+		" --- at github.com/Tanium-OSS/terror/testdata_test.go:13 (tryButFail) ---\n"+
+		"caused by some error",
+		fmt.Sprintf("%+v", WithHint(tryButFail(), "check the input")))
+
+	// Like the errors they annotate, "%v" prints the detailed format.
+	assert.Equal(t, fmt.Sprintf("%+v", err), fmt.Sprintf("%v", err))
+	assert.Equal(t, fmt.Sprintf("%+v", WithDetail(tryButFail(), "x")), fmt.Sprintf("%v", WithDetail(tryButFail(), "x")))
+}
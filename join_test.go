@@ -0,0 +1,47 @@
+//go:build go1.20
+
+package terror
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCombine_Join(t *testing.T) {
+	other := errors.New("other")
+	err := Append(errors.Join(errSentinel, other), io.EOF)
+	assert.Equal(t, []error{errSentinel, other, io.EOF}, err.(*multiError).errs) //nolint:errorlint
+
+	// Combined errors can in turn be joined by the standard library.
+	err = errors.Join(Append(errSentinel, other), io.EOF)
+	assert.ErrorIs(t, err, other)
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestCombine_SeveralWrapped(t *testing.T) {
+	a, b := errors.New("a"), errors.New("b")
+	err := Append(errors.New("primary"), fmt.Errorf("reading config.json: %w; %w", a, b))
+	assert.EqualError(t, err, "primary; reading config.json: a; b")
+	assert.Len(t, err.(*multiError).errs, 2) //nolint:errorlint
+	assert.ErrorIs(t, err, b)
+}
+
+func TestProto_Join(t *testing.T) {
+	// The text of a join is redacted as a whole, but its branches are kept.
+	err := Append(New("first"), Wrap(errors.Join(errSentinel, Const("second")), "joined"))
+	decoded := DecodeProto(EncodeProto(err))
+	assert.EqualError(t, decoded, "first; joined: "+RedactionMarker)
+	assert.ErrorIs(t, decoded, Const("second"))
+}
+
+func TestProto_SeveralWrapped(t *testing.T) {
+	err := fmt.Errorf("loading: %w; %w", errTestKind.New("config"), errProtoForeign)
+	decoded := DecodeProto(EncodeProto(err))
+	assert.EqualError(t, decoded, RedactionMarker)
+	assert.ErrorIs(t, decoded, errTestKind)
+	assert.Len(t, decoded.(interface{ Unwrap() []error }).Unwrap(), 2) //nolint:errorlint
+}
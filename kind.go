@@ -0,0 +1,78 @@
+package terror
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Kind defines a class of errors that carries an error code. Like Const, a Kind is a sentinel that
+// can be compared against using errors.Is, but its New and Wrap methods produce located errors that
+// continue to match the Kind and report its code via GetCode. Kinds are intended to be declared
+// once as package variables:
+//
+//	var ErrNotFound = terror.NewKind("not found", 404)
+//
+// All Kinds are recorded when created and can be enumerated with Kinds, e.g. to generate
+// documentation of the error codes a program can produce.
+type Kind struct {
+	msg  string
+	code int
+}
+
+var kinds struct {
+	sync.Mutex
+	all []*Kind
+}
+
+// NewKind creates and registers a new Kind with the specified message and error code.
+func NewKind(msg string, code int) *Kind {
+	k := &Kind{msg: msg, code: code}
+	kinds.Lock()
+	kinds.all = append(kinds.all, k)
+	kinds.Unlock()
+	return k
+}
+
+// Kinds returns all Kinds created so far, in the order in which they were created.
+func Kinds() []*Kind {
+	kinds.Lock()
+	defer kinds.Unlock()
+	return append([]*Kind(nil), kinds.all...)
+}
+
+// Error implements the conventional interface for representing an error condition.
+func (k *Kind) Error() string { return k.msg }
+
+// Code returns the error code associated with this Kind.
+func (k *Kind) Code() int { return k.code }
+
+// New creates an error of this Kind with the specified message as well as the location of this
+// call. The format and args are formatted printf style and prefixed to the Kind's message, as if
+// the Kind itself had been wrapped.
+func (k *Kind) New(format string, args ...interface{}) error {
+	return kindError{report(std.newError(k, format, args, 1), k.code, false), k}
+}
+
+// Wrap annotates the provided error with the file and line of the call along with the provided
+// message, and marks it as being of this Kind. The result matches both err and the Kind via
+// errors.Is. The format and args are formatted printf style. If err is nil, Wrap returns nil.
+func (k *Kind) Wrap(err error, format string, args ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	return kindError{report(std.newError(err, format, args, 1), k.code, true), k}
+}
+
+// kindError marks an error as being of a particular Kind. base may not be nil.
+type kindError struct {
+	base error
+	kind *Kind
+}
+
+func (e kindError) Unwrap() error { return e.base }
+func (e kindError) Error() string { return e.base.Error() }
+func (e kindError) Is(target error) bool {
+	return target == e.kind || target == CodeTarget(e.kind.code) //nolint:errorlint
+}
+func (e kindError) errorCode() int             { return e.kind.code }
+func (e kindError) Format(f fmt.State, c rune) { formatBase(e.base, f, c) }
@@ -0,0 +1,64 @@
+package terror
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errTestKind = NewKind("not found", 404)
+
+func ExampleKind() {
+	err := errTestKind.New("user %q", "gopher")
+	fmt.Println(err.Error())
+	fmt.Println(errors.Is(err, errTestKind), GetCode(err))
+	// Output:
+	// user "gopher": not found
+	// true 404
+}
+
+func TestKind_New(t *testing.T) {
+	err := errTestKind.New("looking up %d", 12)
+	assert.EqualError(t, err, "looking up 12: not found")
+	assert.ErrorIs(t, err, errTestKind)
+	assert.Equal(t, 404, GetCode(err))
+
+	var located Error
+	assert.True(t, errors.As(err, &located))
+	assert.Equal(t, "kind_test.go", filepath.Base(located.Location().File))
+	assert.Equal(t, "TestKind_New", located.Location().Function)
+}
+
+func TestKind_Wrap(t *testing.T) {
+	assert.Nil(t, errTestKind.Wrap(nil, "nothing"))
+
+	err := errTestKind.Wrap(errSentinel, "looking up %d", 12)
+	assert.EqualError(t, err, "looking up 12: some error")
+	assert.ErrorIs(t, err, errTestKind)
+	assert.ErrorIs(t, err, errSentinel)
+	assert.Equal(t, 404, GetCode(err))
+
+	// Further wrapping keeps both the kind and the code.
+	err = Wrap(err, "outer")
+	assert.ErrorIs(t, err, errTestKind)
+	assert.Equal(t, 404, GetCode(err))
+
+	// A more recent code takes precedence, as with WrapWithCode.
+	assert.Equal(t, 7, GetCode(WrapWithCode(err, 7, "recoded")))
+	assert.Equal(t, 404, GetCode(errTestKind.Wrap(NewWithCode(7, "coded"), "kind")))
+
+	other := NewKind("other", 1)
+	assert.NotErrorIs(t, err, other)
+}
+
+func TestKinds(t *testing.T) {
+	k := NewKind("enumerated", 99)
+	all := Kinds()
+	assert.Contains(t, all, errTestKind)
+	assert.Equal(t, k, all[len(all)-1])
+	assert.Equal(t, "enumerated", k.Error())
+	assert.Equal(t, 99, k.Code())
+}
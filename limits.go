@@ -0,0 +1,60 @@
+package terror
+
+import "unicode/utf8"
+
+// Limits caps the size of formatted errors, for errors that grow to many layers or wrap large
+// messages such as response bodies. Limits apply to Error() and the detailed format, and do not
+// affect the data of an error: Message, Location and Unwrap still return it in full. Zero fields
+// impose no limit.
+type Limits struct {
+	// MaxLength caps the length in bytes of the formatted error.
+	MaxLength int
+	// MaxMessageLength caps the length in bytes of the message of each layer, including the
+	// text of the root error if it was not created by this package.
+	MaxMessageLength int
+	// MaxLayers caps the number of layers formatted. Layers in the middle of the chain are
+	// replaced by "... N more layers ...", always keeping the outermost layer and the root
+	// error.
+	MaxLayers int
+}
+
+// ellipsis marks text truncated by a limit.
+const ellipsis = "..."
+
+// truncate shortens s to at most max bytes, ending in an ellipsis, if it is longer. If max is too
+// small for an ellipsis, s is cut without one.
+func truncate(s string, max int) string {
+	if max <= 0 || len(s) <= max {
+		return s
+	}
+	if max < len(ellipsis) {
+		return s[:runeCut(s, max)]
+	}
+	return s[:runeCut(s, max-len(ellipsis))] + ellipsis
+}
+
+// runeCut returns the largest index of s no greater than n that doesn't split a rune.
+func runeCut(s string, n int) int {
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return n
+}
+
+// elide returns how many of n layers to keep before eliding the middle of the chain, and how many
+// to elide, so that at most max are kept including the last.
+func elide(n, max int) (head, elided int) {
+	if max <= 0 || n <= max {
+		return n, 0
+	}
+	if max < 2 {
+		max = 2
+	}
+	head = (max + 1) / 2
+	return head, n - max
+}
+
+// elision stands in for elided layers.
+func elision(elided int) string {
+	return ellipsis + " " + plural(elided, "more layer") + " " + ellipsis
+}
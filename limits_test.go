@@ -0,0 +1,84 @@
+package terror
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimits_MaxLayers(t *testing.T) {
+	w := NewWrapper(WithLimits(Limits{MaxLayers: 3}))
+	err := errSentinel
+	// Alternate templates so that the layers are not collapsed as repeats.
+	formats := []string{"layer %d", "step %d"}
+	for i := 0; i < 6; i++ {
+		err = w.Wrap(err, formats[i%2], i)
+	}
+	assert.Equal(t, "step 5: layer 4: ... 4 more layers ...: some error", err.Error())
+
+	lines := strings.Split(fmt.Sprintf("%+v", err), "\n")
+	require.Len(t, lines, 6)
+	assert.Equal(t, "step 5", lines[0])
+	assert.Regexp(t, `^ --- at .*/limits_test.go:\d+ \(TestLimits_MaxLayers\) ---$`, lines[1])
+	assert.Equal(t, "caused by layer 4", lines[2])
+	assert.Regexp(t, `^ --- at .*/limits_test.go:\d+ \(TestLimits_MaxLayers\) ---$`, lines[3])
+	assert.Equal(t, "... 4 more layers ...", lines[4])
+	assert.Equal(t, "caused by some error", lines[5])
+
+	// The outermost layer and the root are always kept.
+	w = NewWrapper(WithLimits(Limits{MaxLayers: 1}))
+	err = w.Wrap(w.Wrap(w.New("root"), "middle"), "outer")
+	assert.Equal(t, "outer: ... 1 more layer ...: root", err.Error())
+	assert.Equal(t, "root", err.(TError).Unwrap().(TError).Unwrap().(TError).Message()) //nolint:errorlint
+
+	// Chains within the limit are unchanged.
+	w = NewWrapper(WithLimits(Limits{MaxLayers: 3}))
+	assert.Equal(t, "outer: middle: root", w.Wrap(w.Wrap(w.New("root"), "middle"), "outer").Error())
+}
+
+func TestLimits_MaxMessageLength(t *testing.T) {
+	w := NewWrapper(WithLimits(Limits{MaxMessageLength: 10}))
+	body := errors.New(`{"error": "a very long response body"}`)
+	err := w.Wrap(w.Wrap(body, "request failed with status %d", 500), "short")
+	assert.Equal(t, `short: request...: {"error...`, err.Error())
+	assert.Regexp(t, ""+
+		`^short\n`+
+		` --- at .*/limits_test.go:\d+ \(TestLimits_MaxMessageLength\) ---\n`+
+		`caused by request\.\.\.\n`+
+		` --- at .*/limits_test.go:\d+ \(TestLimits_MaxMessageLength\) ---\n`+
+		`caused by \{"error\.\.\.$`,
+		fmt.Sprintf("%+v", err))
+
+	// The full message is still available.
+	assert.Equal(t, "request failed with status 500", errors.Unwrap(err).(TError).Message()) //nolint:errorlint
+}
+
+func TestLimits_MaxLength(t *testing.T) {
+	defer SetConfig(Config{})
+	SetConfig(Config{Limits: Limits{MaxLength: 20}})
+
+	err := Wrap(tryButFail(), "outer")
+	assert.Equal(t, "outer: trying som...", err.Error())
+	detail := fmt.Sprintf("%+v", err)
+	assert.Len(t, detail, 20)
+	assert.Equal(t, "outer\n --- at git...", detail)
+
+	// Wrappers with their own limits are unaffected.
+	w := NewWrapper(WithLimits(Limits{MaxLayers: 10}))
+	assert.Equal(t, "outer: trying something: some error", w.Wrap(errors.Unwrap(err), "outer").Error())
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "hello", truncate("hello", 0))
+	assert.Equal(t, "hello", truncate("hello", 5))
+	assert.Equal(t, "h...", truncate("hello", 4))
+	assert.Equal(t, "he", truncate("hello", 2))
+	// Multi-byte characters are not split.
+	assert.Equal(t, "h...", truncate("héllo", 5))
+	assert.Equal(t, "hé...", truncate("héllo!", 6))
+	assert.Equal(t, "h", truncate("héllo", 2))
+}
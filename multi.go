@@ -0,0 +1,129 @@
+package terror
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Append combines left and right into a single error, recording the location of this call as
+// where each of them was appended. Either may be nil, in which case the other is returned as is.
+// Errors that are already combined, including by errors.Join or go.uber.org/multierr, are
+// flattened into the result.
+func Append(left, right error) error {
+	return combine([]error{left, right}, 1)
+}
+
+// Combine combines errs into a single error, like Append. nil errors are ignored, and if errs
+// contains a single error it is returned as is. If errs contains no errors, Combine returns nil.
+func Combine(errs ...error) error {
+	return combine(errs, 1)
+}
+
+// AppendInto appends err into *into, like Append, and reports whether err was non-nil. This is
+// convenient with named return variables:
+//
+//	defer func() {
+//		terror.AppendInto(&err, cleanup())
+//	}()
+func AppendInto(into *error, err error) bool {
+	if err == nil {
+		return false
+	}
+	*into = combine([]error{*into, err}, 1)
+	return true
+}
+
+// appendInto is like AppendInto, but records the location of the caller skip frames above the
+// caller of appendInto.
+func appendInto(into *error, err error, skip int) {
+	if err != nil {
+		*into = combine([]error{*into, err}, skip+1)
+	}
+}
+
+// multiError is a combination of errors created by Append, Combine or AppendInto. It is used as a
+// pointer so that it remains comparable.
+type multiError struct {
+	errs []error
+	// locs holds the location at which each of errs was appended.
+	locs []Location
+}
+
+func combine(errs []error, skip int) error {
+	var loc Location
+	m := &multiError{}
+	for _, err := range errs {
+		switch err := err.(type) { //nolint:errorlint
+		case nil:
+		case *multiError:
+			m.errs = append(m.errs, err.errs...)
+			m.locs = append(m.locs, err.locs...)
+		default:
+			if loc == (Location{}) {
+				loc = capture(skip + 1)
+			}
+			for _, err := range flatten(err) {
+				m.errs = append(m.errs, err)
+				m.locs = append(m.locs, loc)
+			}
+		}
+	}
+	switch len(m.errs) {
+	case 0:
+		return nil
+	case 1:
+		return m.errs[0]
+	}
+	return m
+}
+
+// flatten returns the errors combined in a foreign multi-error, created by go.uber.org/multierr or
+// errors.Join, or err itself. Other errors that wrap several, such as those created by fmt.Errorf
+// with more than one %w verb, are kept whole, as they have text of their own.
+func flatten(err error) []error {
+	if multi, ok := err.(interface{ Errors() []error }); ok { //nolint:errorlint
+		return multi.Errors()
+	}
+	if multi, ok := err.(interface{ Unwrap() []error }); ok && reflect.TypeOf(err).String() == "*errors.joinError" { //nolint:errorlint
+		return multi.Unwrap()
+	}
+	return []error{err}
+}
+
+// Unwrap returns the combined errors, implementing the go1.20 error unwrapping to support
+// errors.Is and errors.As. Before Go 1.20, the Is and As methods support them instead.
+func (m *multiError) Unwrap() []error { return append([]error(nil), m.errs...) }
+
+// Errors returns the combined errors, for compatibility with go.uber.org/multierr.Errors.
+func (m *multiError) Errors() []error { return m.Unwrap() }
+
+// Error returns the messages of the combined errors, separated by semicolons.
+func (m *multiError) Error() string {
+	msgs := make([]string, len(m.errs))
+	for i, err := range m.errs {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Format implements fmt.Formatter. Like the errors created by this package, "%v" and "%+v" print
+// each of the combined errors in its detailed format, followed by where it was appended, and every
+// other verb prints the messages on a single line.
+func (m *multiError) Format(f fmt.State, c rune) {
+	if c != 'v' {
+		io.WriteString(f, m.Error())
+		return
+	}
+	io.WriteString(f, strconv.Itoa(len(m.errs))+" errors occurred:")
+	for i, err := range m.errs {
+		detail := fmt.Sprintf("%+v", err)
+		if m.locs[i] != (Location{}) {
+			detail += "\n --- appended at " + m.locs[i].String() + " ---"
+		}
+		io.WriteString(f, "\n * ")
+		io.WriteString(f, strings.ReplaceAll(detail, "\n", "\n   "))
+	}
+}
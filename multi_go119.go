@@ -0,0 +1,31 @@
+//go:build !go1.20
+
+package terror
+
+import "errors"
+
+// Before Go 1.20, errors.Is and errors.As do not follow Unwrap() []error, so the errors that
+// combine others implement Is and As themselves.
+
+func (m *multiError) Is(target error) bool       { return anyIs(m.errs, target) }
+func (m *multiError) As(target interface{}) bool { return anyAs(m.errs, target) }
+
+// anyIs reports whether any of errs matches target according to errors.Is.
+func anyIs(errs []error, target error) bool {
+	for _, err := range errs {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// anyAs finds the first of errs that matches target according to errors.As.
+func anyAs(errs []error, target interface{}) bool {
+	for _, err := range errs {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+package terror
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ExampleAppendInto() {
+	errExample := func() (err error) {
+		defer func() {
+			AppendInto(&err, errors.New("could not flush"))
+		}()
+		return New("could not write")
+	}()
+
+	fmt.Println(errExample.Error())
+	// Output: could not write; could not flush
+}
+
+func TestAppend(t *testing.T) {
+	assert.Nil(t, Append(nil, nil))
+	assert.Equal(t, errSentinel, Append(errSentinel, nil))
+	assert.Equal(t, errSentinel, Append(nil, errSentinel))
+
+	other := errors.New("other")
+	err := Append(errSentinel, other)
+	assert.EqualError(t, err, "some error; other")
+	assert.ErrorIs(t, err, errSentinel)
+	assert.ErrorIs(t, err, other)
+	assert.Equal(t, []error{errSentinel, other}, err.(*multiError).errs) //nolint:errorlint
+
+	// Appending to a combined error flattens it.
+	err = Append(err, io.EOF)
+	assert.Equal(t, []error{errSentinel, other, io.EOF}, err.(*multiError).errs) //nolint:errorlint
+}
+
+func TestCombine(t *testing.T) {
+	assert.Nil(t, Combine())
+	assert.Nil(t, Combine(nil, nil))
+	assert.Equal(t, errSentinel, Combine(nil, errSentinel, nil))
+
+	err := Combine(New("first"), nil, NewWithCode(7, "second"))
+	assert.EqualError(t, err, "first; second")
+	assert.Equal(t, 7, GetCode(err))
+
+	var located Error
+	require.True(t, errors.As(err, &located))
+	assert.Equal(t, "TestCombine", located.Location().Function)
+}
+
+func TestAppendInto(t *testing.T) {
+	var err error
+	assert.False(t, AppendInto(&err, nil))
+	assert.NoError(t, err)
+	assert.True(t, AppendInto(&err, errSentinel))
+	assert.Equal(t, errSentinel, err)
+	assert.True(t, AppendInto(&err, io.EOF))
+	assert.EqualError(t, err, "some error; EOF")
+}
+
+func TestCombine_Foreign(t *testing.T) {
+	other := errors.New("other")
+	err := Append(foreignMulti{errSentinel, other}, io.EOF)
+	assert.Equal(t, []error{errSentinel, other, io.EOF}, err.(*multiError).errs) //nolint:errorlint
+
+	// Collected errors wrap several, but are kept whole with their summary.
+	c := &Collector{}
+	c.Add(errSentinel, "a")
+	c.Add(other, "b")
+	err = Append(io.EOF, c.Err())
+	assert.EqualError(t, err, "EOF; 2 errors (1 distinct), first: some error")
+	assert.Len(t, err.(*multiError).errs, 2) //nolint:errorlint
+}
+
+// foreignMulti is a multi-error not created by this package, like those of go.uber.org/multierr.
+type foreignMulti []error
+
+func (m foreignMulti) Error() string   { return fmt.Sprint([]error(m)) }
+func (m foreignMulti) Errors() []error { return m }
+
+func TestMultiError_Format(t *testing.T) {
+	err := Append(New("first"), Wrap(errSentinel, "second"))
+
+	assert.Equal(t, "first; second: some error", fmt.Sprintf("%s", err))
+	assert.Equal(t, fmt.Sprintf("%+v", err), fmt.Sprintf("%v", err))
+
+	lines := strings.Split(fmt.Sprintf("%+v", err), "\n")
+	require.Len(t, lines, 8)
+	assert.Equal(t, "2 errors occurred:", lines[0])
+	assert.Equal(t, " * first", lines[1])
+	assert.Regexp(t, `^    --- at github.com/Tanium-OSS/terror/multi_test.go:\d+ \(TestMultiError_Format\) ---$`, lines[2])
+	assert.Regexp(t, `^    --- appended at github.com/Tanium-OSS/terror/multi_test.go:\d+ \(TestMultiError_Format\) ---$`, lines[3])
+	assert.Equal(t, " * second", lines[4])
+	assert.Regexp(t, `^    --- at github.com/Tanium-OSS/terror/multi_test.go:\d+ \(TestMultiError_Format\) ---$`, lines[5])
+	assert.Equal(t, "   caused by some error", lines[6])
+	assert.Regexp(t, `^    --- appended at github.com/Tanium-OSS/terror/multi_test.go:\d+ \(TestMultiError_Format\) ---$`, lines[7])
+
+	// Errors that were already combined keep where they were appended.
+	first := fmt.Sprintf("%+v", err)
+	err = Append(err, io.EOF)
+	detail := fmt.Sprintf("%+v", err)
+	assert.Equal(t, "3 errors occurred:"+strings.TrimPrefix(first, "2 errors occurred:")+"\n * EOF\n", detail[:len(first)+len(" * EOF\n")+1])
+	assert.Regexp(t, `\n \* EOF\n    --- appended at github.com/Tanium-OSS/terror/multi_test.go:\d+ \(TestMultiError_Format\) ---$`, detail)
+}
+
+func TestMultiError_Wrapped(t *testing.T) {
+	err := Wrap(Append(errSentinel, io.EOF), "closing")
+	assert.EqualError(t, err, "closing: some error; EOF")
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Contains(t, fmt.Sprintf("%+v", err), "caused by 2 errors occurred:\n * some error\n")
+}
+
+func TestFingerprint_Multi(t *testing.T) {
+	other := Const("other")
+	a, b := Append(errSentinel, other), Append(errSentinel, other)
+	assert.Equal(t, Fingerprint(a), Fingerprint(b))
+	assert.NotEqual(t, Fingerprint(a), Fingerprint(Append(other, errSentinel)))
+	assert.NotEqual(t, Fingerprint(a), Fingerprint(errSentinel))
+}
+
+func TestRedacted_Multi(t *testing.T) {
+	err := Append(New("user %s", "gopher"), Const("fixed"))
+	assert.Equal(t, "user "+RedactionMarker+"; fixed", Redacted(err))
+	assert.Equal(t, "outer: user "+RedactionMarker+"; fixed", Redacted(Wrap(err, "outer")))
+}
@@ -0,0 +1,17 @@
+module github.com/Tanium-OSS/terror/multierrtest
+
+go 1.19
+
+require (
+	github.com/Tanium-OSS/terror v0.0.0
+	github.com/stretchr/testify v1.8.0
+	go.uber.org/multierr v1.11.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/Tanium-OSS/terror => ../
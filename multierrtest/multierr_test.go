@@ -0,0 +1,31 @@
+// Package multierrtest tests the interoperation of terror with go.uber.org/multierr. It is a
+// separate module so that terror itself does not depend on multierr, even for its tests.
+package multierrtest
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/Tanium-OSS/terror"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/multierr"
+)
+
+var errSentinel = errors.New("some error")
+
+func TestErrors(t *testing.T) {
+	other := errors.New("other")
+	err := terror.Append(errSentinel, other)
+	assert.Equal(t, []error{errSentinel, other}, multierr.Errors(err))
+
+	// Errors combined by multierr are flattened.
+	err = terror.Append(multierr.Combine(errSentinel, other), io.EOF)
+	assert.Equal(t, []error{errSentinel, other, io.EOF}, multierr.Errors(err))
+	assert.Equal(t, multierr.Combine(errSentinel, other, io.EOF).Error(), err.Error())
+}
+
+func TestGetCode(t *testing.T) {
+	// multierr picks the first error in the chain
+	assert.Equal(t, 11, terror.GetCode(multierr.Combine(terror.NewWithCode(11, "foo"), terror.NewWithCode(12, "foo"))))
+}
@@ -0,0 +1,482 @@
+package terror
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ErrMalformedProto is matched, according to errors.Is, by the errors that DecodeProto returns for
+// input that is not an error chain encoded by EncodeProto.
+const ErrMalformedProto = Const("malformed terror protobuf encoding")
+
+// protoVersion is the version of the encoding written by EncodeProto, as described in terror.proto.
+const protoVersion = 1
+
+// protoMaxDepth is how deeply DecodeProto allows multi-errors to be nested.
+const protoMaxDepth = 100
+
+// EncodeProto encodes the chain of err as a Chain message, described by terror.proto, so that it
+// can be sent to another process and restored by DecodeProto. Each error created by this package
+// is encoded with its redacted message, its safe arguments, location, code and annotations: hints
+// and details with their arguments redacted like those of messages, and user messages as they are,
+// as they are written to be shown to users. Other errors are encoded with their Go type and their
+// text as redacted by Redacted. No argument or error text that Redacted would replace is encoded.
+// If err is nil, EncodeProto returns nil.
+func EncodeProto(err error) []byte {
+	if err == nil {
+		return nil
+	}
+	return protoChainOf(err).append(nil, protoVersion)
+}
+
+// DecodeProto decodes an error chain encoded by EncodeProto. The result formats like the original
+// with sensitive information redacted, including its locations in the detailed format, and matches
+// the same Kinds, codes and Consts according to errors.Is. Errors that were not created by this
+// package are restored with their text, but not their types. If b is empty, DecodeProto returns
+// nil. If b is not a valid encoding, DecodeProto returns an error matching ErrMalformedProto
+// instead.
+func DecodeProto(b []byte) error {
+	if len(b) == 0 {
+		return nil
+	}
+	c, version, err := parseProtoChain(b, 0)
+	if err != nil {
+		return err
+	}
+	if version != protoVersion {
+		return Wrap(ErrMalformedProto, "unsupported version %d", version)
+	}
+	return c.error()
+}
+
+// protoChain is a Chain message.
+type protoChain struct {
+	layers   []protoLayer
+	appended Location
+}
+
+// protoLayer is a Layer message.
+type protoLayer struct {
+	message     string
+	template    string
+	safeArgs    []string
+	code        int
+	kind        string
+	loc         Location
+	fields      []protoField
+	foreignType string
+	branches    []protoChain
+}
+
+// protoField is a Field message.
+type protoField struct {
+	key, value string
+}
+
+// The keys of the fields of a layer.
+const (
+	protoSeverity    = "severity"
+	protoHint        = "hint"
+	protoDetail      = "detail"
+	protoUserMessage = "user_message"
+)
+
+// protoChainOf returns the layers of the chain of err.
+func protoChainOf(err error) protoChain {
+	var c protoChain
+	var l protoLayer
+	for ; err != nil; err = errors.Unwrap(err) {
+		switch e := err.(type) { //nolint:errorlint
+		case codeError:
+			if l.code == 0 {
+				l.code = e.code
+			}
+			continue
+		case kindError:
+			if l.code == 0 {
+				l.code, l.kind = e.kind.code, e.kind.msg
+			}
+			continue
+		case severityError:
+			l.fields = append(l.fields, protoField{protoSeverity, strconv.Itoa(int(e.severity))})
+			continue
+		case hintError:
+			l.fields = append(l.fields, protoField{protoHint, e.redactedHint()})
+			continue
+		case detailError:
+			l.fields = append(l.fields, protoField{protoDetail, e.redactedDetail()})
+			continue
+		case userMessageError:
+			l.fields = append(l.fields, protoField{protoUserMessage, e.msg})
+			continue
+		case TError:
+			l.message, l.template, l.loc = e.redactedMsg(), e.format, e.loc
+			if e.redaction != nil {
+				l.safeArgs = e.redaction.safe
+			}
+		case *multiError:
+			for i, err := range e.errs {
+				branch := protoChainOf(err)
+				branch.appended = e.locs[i]
+				l.branches = append(l.branches, branch)
+			}
+		case remoteError:
+			l.foreignType, l.message = e.typeName, e.msg
+		case *remoteJoin:
+			l.foreignType, l.message = e.typeName, e.msg
+			for _, err := range e.errs {
+				l.branches = append(l.branches, protoChainOf(err))
+			}
+		default:
+			l.foreignType, l.message = fmt.Sprintf("%T", err), redactedText(err)
+			if kind, ok := err.(*Kind); ok && l.code == 0 { //nolint:errorlint
+				l.code = kind.code
+			}
+			// Errors that wrap several, whether they combine them or have text of their own,
+			// keep their text and the chains they wrap.
+			if multi, ok := err.(interface{ Unwrap() []error }); ok { //nolint:errorlint
+				for _, err := range multi.Unwrap() {
+					l.branches = append(l.branches, protoChainOf(err))
+				}
+			}
+		}
+		c.layers = append(c.layers, l)
+		l = protoLayer{}
+	}
+	return c
+}
+
+// error returns the error chain c describes.
+func (c protoChain) error() error {
+	var err error
+	for i := len(c.layers) - 1; i >= 0; i-- {
+		err = c.layers[i].error(err, i == len(c.layers)-1)
+	}
+	return err
+}
+
+// error returns the error l describes, wrapping base. root is whether l is the last layer.
+func (l protoLayer) error(base error, root bool) error {
+	var err error
+	switch {
+	case len(l.branches) > 0:
+		var errs []error
+		var locs []Location
+		for _, branch := range l.branches {
+			if err := branch.error(); err != nil {
+				errs = append(errs, err)
+				locs = append(locs, branch.appended)
+			}
+		}
+		if l.foreignType == "" {
+			err = &multiError{errs, locs}
+		} else {
+			err = &remoteJoin{l.foreignType, l.message, errs}
+		}
+	case l.foreignType != "":
+		err = l.foreignError(base, root)
+	default:
+		t := TError{base: box(base), msg: l.message, format: l.template, loc: l.loc}
+		if len(l.safeArgs) > 0 {
+			t.redaction = &redaction{msg: l.message, safe: l.safeArgs}
+		}
+		err = t
+		if kind := findKind(l.kind, l.code); kind != nil {
+			err = kindError{t, kind}
+		} else if l.code != 0 {
+			err = codeError{t, l.code}
+		}
+	}
+	for i := len(l.fields) - 1; i >= 0; i-- {
+		switch field := l.fields[i]; field.key {
+		case protoSeverity:
+			if severity, convErr := strconv.Atoi(field.value); convErr == nil {
+				err = severityError{err, SeverityLevel(severity)}
+			}
+		case protoHint:
+			err = hintError{base: err, hint: field.value}
+		case protoDetail:
+			err = detailError{base: err, detail: field.value}
+		case protoUserMessage:
+			err = userMessageError{err, field.value}
+		}
+	}
+	return err
+}
+
+// foreignError returns the error that l describes if it was not created by this package. Consts
+// and registered Kinds are restored as themselves when they are the root error.
+func (l protoLayer) foreignError(base error, root bool) error {
+	if root {
+		switch l.foreignType {
+		case fmt.Sprintf("%T", Const("")):
+			return codeOf(Const(l.message), l.code)
+		case fmt.Sprintf("%T", &Kind{}):
+			if kind := findKind(l.message, l.code); kind != nil {
+				return kind
+			}
+		}
+	}
+	return codeOf(remoteError{l.foreignType, l.message, base}, l.code)
+}
+
+// codeOf returns err with code added, if it is not 0.
+func codeOf(err error, code int) error {
+	if code == 0 {
+		return err
+	}
+	return codeError{err, code}
+}
+
+// findKind returns the registered Kind with the given message and code, or nil if there is none.
+func findKind(msg string, code int) *Kind {
+	if msg == "" {
+		return nil
+	}
+	for _, kind := range Kinds() {
+		if kind.msg == msg && kind.code == code {
+			return kind
+		}
+	}
+	return nil
+}
+
+// remoteError stands in for a decoded error that was not created by this package. It has the
+// redacted text of the original error, and wraps the layers that followed it, if any.
+type remoteError struct {
+	typeName string
+	msg      string
+	base     error
+}
+
+func (e remoteError) Error() string { return e.msg }
+func (e remoteError) Unwrap() error { return e.base }
+
+// remoteJoin stands in for a decoded multi-error that was not created by this package, such as one
+// created by errors.Join, with its redacted text.
+type remoteJoin struct {
+	typeName string
+	msg      string
+	errs     []error
+}
+
+func (e *remoteJoin) Error() string   { return e.msg }
+func (e *remoteJoin) Unwrap() []error { return append([]error(nil), e.errs...) }
+
+// The field numbers of the messages in terror.proto.
+const (
+	chainVersion  = 1
+	chainLayers   = 2
+	chainAppended = 3
+
+	layerMessage     = 1
+	layerTemplate    = 2
+	layerCode        = 3
+	layerKind        = 4
+	layerLocation    = 5
+	layerFields      = 6
+	layerForeignType = 7
+	layerBranches    = 8
+	layerSafeArgs    = 9
+
+	locationFile     = 1
+	locationLine     = 2
+	locationFunction = 3
+
+	fieldKey   = 1
+	fieldValue = 2
+)
+
+// The protobuf wire types.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// append appends the encoding of c to b, with the given version, which is 0 for branches.
+func (c protoChain) append(b []byte, version uint64) []byte {
+	b = appendVarintField(b, chainVersion, version)
+	for _, l := range c.layers {
+		b = appendBytesField(b, chainLayers, l.append(nil))
+	}
+	if c.appended != (Location{}) {
+		b = appendBytesField(b, chainAppended, appendLocation(nil, c.appended))
+	}
+	return b
+}
+
+func (l protoLayer) append(b []byte) []byte {
+	b = appendStringField(b, layerMessage, l.message)
+	b = appendStringField(b, layerTemplate, l.template)
+	b = appendVarintField(b, layerCode, zigzag(int64(l.code)))
+	b = appendStringField(b, layerKind, l.kind)
+	if l.loc != (Location{}) {
+		b = appendBytesField(b, layerLocation, appendLocation(nil, l.loc))
+	}
+	for _, field := range l.fields {
+		f := appendStringField(nil, fieldKey, field.key)
+		f = appendStringField(f, fieldValue, field.value)
+		b = appendBytesField(b, layerFields, f)
+	}
+	b = appendStringField(b, layerForeignType, l.foreignType)
+	for _, branch := range l.branches {
+		b = appendBytesField(b, layerBranches, branch.append(nil, 0))
+	}
+	for _, arg := range l.safeArgs {
+		b = appendBytesField(b, layerSafeArgs, []byte(arg))
+	}
+	return b
+}
+
+func appendLocation(b []byte, loc Location) []byte {
+	b = appendStringField(b, locationFile, loc.File)
+	b = appendVarintField(b, locationLine, uint64(loc.Line))
+	return appendStringField(b, locationFunction, loc.Function)
+}
+
+// appendVarintField appends a varint field to b, unless v is 0, the default.
+func appendVarintField(b []byte, num int, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = binary.AppendUvarint(b, uint64(num)<<3|wireVarint)
+	return binary.AppendUvarint(b, v)
+}
+
+// appendStringField appends a string field to b, unless s is empty, the default.
+func appendStringField(b []byte, num int, s string) []byte {
+	if s == "" {
+		return b
+	}
+	return appendBytesField(b, num, []byte(s))
+}
+
+func appendBytesField(b []byte, num int, v []byte) []byte {
+	b = binary.AppendUvarint(b, uint64(num)<<3|wireBytes)
+	b = binary.AppendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+// zigzag encodes v as a sint64 varint, so that small negative numbers are encoded compactly.
+func zigzag(v int64) uint64 { return uint64(v<<1) ^ uint64(v>>63) }
+
+func unzigzag(v uint64) int64 { return int64(v>>1) ^ -int64(v&1) }
+
+// parseProtoChain decodes the Chain message in b, which is nested in depth multi-errors.
+func parseProtoChain(b []byte, depth int) (c protoChain, version uint64, err error) {
+	if depth > protoMaxDepth {
+		return c, 0, Wrap(ErrMalformedProto, "multi-errors nested more than %d deep", protoMaxDepth)
+	}
+	err = parseProtoMessage(b, func(num int, v uint64, b []byte) error {
+		switch num {
+		case chainVersion:
+			version = v
+		case chainLayers:
+			l, err := parseProtoLayer(b, depth)
+			c.layers = append(c.layers, l)
+			return err
+		case chainAppended:
+			return parseProtoLocation(b, &c.appended)
+		}
+		return nil
+	})
+	return c, version, err
+}
+
+func parseProtoLayer(b []byte, depth int) (l protoLayer, err error) {
+	err = parseProtoMessage(b, func(num int, v uint64, b []byte) error {
+		switch num {
+		case layerMessage:
+			l.message = string(b)
+		case layerTemplate:
+			l.template = string(b)
+		case layerCode:
+			l.code = int(unzigzag(v))
+		case layerKind:
+			l.kind = string(b)
+		case layerLocation:
+			return parseProtoLocation(b, &l.loc)
+		case layerFields:
+			l.fields = append(l.fields, protoField{})
+			return parseProtoMessage(b, func(num int, _ uint64, b []byte) error {
+				switch num {
+				case fieldKey:
+					l.fields[len(l.fields)-1].key = string(b)
+				case fieldValue:
+					l.fields[len(l.fields)-1].value = string(b)
+				}
+				return nil
+			})
+		case layerForeignType:
+			l.foreignType = string(b)
+		case layerBranches:
+			branch, _, err := parseProtoChain(b, depth+1)
+			l.branches = append(l.branches, branch)
+			return err
+		case layerSafeArgs:
+			l.safeArgs = append(l.safeArgs, string(b))
+		}
+		return nil
+	})
+	return l, err
+}
+
+func parseProtoLocation(b []byte, loc *Location) error {
+	return parseProtoMessage(b, func(num int, v uint64, b []byte) error {
+		switch num {
+		case locationFile:
+			loc.File = string(b)
+		case locationLine:
+			loc.Line = int(v)
+		case locationFunction:
+			loc.Function = string(b)
+		}
+		return nil
+	})
+}
+
+// parseProtoMessage calls field for each field of the message in b, with its number and either its
+// value if it is a varint, or its contents if it is length-delimited. Fixed-size fields are
+// skipped, as this encoding has none.
+func parseProtoMessage(b []byte, field func(num int, v uint64, b []byte) error) error {
+	for len(b) > 0 {
+		tag, n := binary.Uvarint(b)
+		if n <= 0 || tag>>3 == 0 || tag>>3 > 1<<29-1 {
+			return Wrap(ErrMalformedProto, "invalid field tag")
+		}
+		b = b[n:]
+		num := int(tag >> 3)
+		var v uint64
+		var contents []byte
+		switch tag & 7 {
+		case wireVarint:
+			if v, n = binary.Uvarint(b); n <= 0 {
+				return Wrap(ErrMalformedProto, "invalid varint in field %d", num)
+			}
+		case wireFixed64:
+			n = 8
+		case wireBytes:
+			length, m := binary.Uvarint(b)
+			if m <= 0 || length > uint64(len(b)-m) {
+				return Wrap(ErrMalformedProto, "invalid length of field %d", num)
+			}
+			contents, n = b[m:m+int(length)], m+int(length)
+		case wireFixed32:
+			n = 4
+		default:
+			return Wrap(ErrMalformedProto, "unsupported wire type %d of field %d", tag&7, num)
+		}
+		if n > len(b) {
+			return Wrap(ErrMalformedProto, "truncated field %d", num)
+		}
+		b = b[n:]
+		if err := field(num, v, contents); err != nil {
+			return err
+		}
+	}
+	return nil
+}
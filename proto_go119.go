@@ -0,0 +1,6 @@
+//go:build !go1.20
+
+package terror
+
+func (e *remoteJoin) Is(target error) bool       { return anyIs(e.errs, target) }
+func (e *remoteJoin) As(target interface{}) bool { return anyAs(e.errs, target) }
@@ -0,0 +1,183 @@
+package terror
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ExampleDecodeProto() {
+	sent := Wrap(errTestKind.New("user %d", Safe(42)), "handling request from %s", "203.0.113.7")
+	received := DecodeProto(EncodeProto(sent))
+	fmt.Println(received.Error())
+	fmt.Println(errors.Is(received, errTestKind), GetCode(received))
+	// Output:
+	// handling request from ‹×›: user 42: not found
+	// true 404
+}
+
+// errProtoForeign is a foreign error whose text is safe to encode.
+var errProtoForeign = errors.New("some error")
+
+func init() {
+	RegisterSafeErrors(errProtoForeign)
+}
+
+// protoRoundTrip encodes and decodes err, which must have nothing to redact, and checks that the
+// result formats like err.
+func protoRoundTrip(t *testing.T, err error) error {
+	t.Helper()
+	require.Equal(t, err.Error(), Redacted(err))
+	decoded := DecodeProto(EncodeProto(err))
+	require.Error(t, decoded)
+	assert.Equal(t, err.Error(), decoded.Error())
+	assert.Equal(t, fmt.Sprintf("%+v", err), fmt.Sprintf("%+v", decoded))
+	return decoded
+}
+
+func TestProto(t *testing.T) {
+	assert.Nil(t, EncodeProto(nil))
+	assert.Nil(t, DecodeProto(nil))
+
+	err := protoRoundTrip(t, Wrap(WithHint(Wrap(errProtoForeign, "trying %s", Safe("2 things")), "try fewer things"), "outer"))
+	assert.Regexp(t, ""+
+		`^outer\n`+
+		` --- at github.com/Tanium-OSS/terror/proto_test.go:\d+ \(TestProto\) ---\n`+
+		` --- hint: try fewer things ---\n`+
+		`caused by trying 2 things\n`+
+		` --- at github.com/Tanium-OSS/terror/proto_test.go:\d+ \(TestProto\) ---\n`+
+		`caused by some error$`,
+		fmt.Sprintf("%+v", err))
+	assert.Equal(t, []string{"try fewer things"}, Hints(err))
+	assert.NotErrorIs(t, err, errProtoForeign, "foreign errors are restored by text only")
+
+	var terr TError
+	require.ErrorAs(t, err, &terr)
+	assert.Equal(t, "outer", terr.Message())
+	assert.Equal(t, "TestProto", terr.Location().Function)
+}
+
+func TestProto_CodesAndKinds(t *testing.T) {
+	err := protoRoundTrip(t, WithSeverity(WrapWithCode(errTestKind.Wrap(errProtoForeign, "reading"), 503, "unavailable"), SeverityCritical))
+	assert.ErrorIs(t, err, errTestKind)
+	assert.ErrorIs(t, err, CodeTarget(404))
+	assert.ErrorIs(t, err, CodeTarget(503))
+	assert.Equal(t, 503, GetCode(err))
+	assert.Equal(t, SeverityCritical, Severity(err))
+
+	err = protoRoundTrip(t, WithUserMessage(WithDetail(Wrap(ErrMalformedProto, "decoding"), "%d bytes", Safe(3)), "Try again."))
+	assert.ErrorIs(t, err, ErrMalformedProto)
+	assert.Equal(t, []string{"3 bytes"}, Details(err))
+	assert.Equal(t, "Try again.", UserMessage(err, "en"))
+
+	// Kinds that are not registered in the decoding process keep their codes only.
+	encoded := EncodeProto(NewKind("only here", 4040).New("remote"))
+	kinds.Lock()
+	kinds.all = kinds.all[:len(kinds.all)-1]
+	kinds.Unlock()
+	err = DecodeProto(encoded)
+	assert.EqualError(t, err, "remote: only here")
+	assert.Equal(t, 4040, GetCode(err))
+	assert.ErrorIs(t, err, CodeTarget(4040))
+}
+
+func TestProto_ForeignErrors(t *testing.T) {
+	// Foreign wrappers keep their redacted text, and the layers they wrap.
+	err := DecodeProto(EncodeProto(Wrap(fmt.Errorf("retrying: %w", New("root")), "giving up")))
+	assert.EqualError(t, err, "giving up: "+RedactionMarker)
+	assert.Equal(t, "giving up: "+RedactionMarker, Redacted(err))
+	var terrs int
+	for ; err != nil; err = errors.Unwrap(err) {
+		if _, ok := err.(TError); ok { //nolint:errorlint
+			terrs++
+		}
+	}
+	assert.Equal(t, 2, terrs)
+
+	err = protoRoundTrip(t, Combine(Wrap(errProtoForeign, "one"), errTestKind.New("two")))
+	assert.ErrorIs(t, err, errTestKind)
+	assert.Len(t, err.(interface{ Unwrap() []error }).Unwrap(), 2) //nolint:errorlint
+}
+
+func TestProto_Redaction(t *testing.T) {
+	RegisterUserMessage(5006, "", "Account {1} is locked.")
+	sent := Wrap(
+		WrapWithCode(fmt.Errorf("token %s rejected", "tok-secret"), 5006, "account %s of %s locked", Safe("ab12"), "alice@example.com"),
+		"logging in with %s", Unsafe("hunter2"))
+	sent = WithDetail(WithHint(sent, "retry after %d seconds", Safe(30)), "request body: %s", "ssn=123-45-6789")
+	encoded := EncodeProto(sent)
+	for _, secret := range []string{"tok-secret", "alice@example.com", "hunter2", "ssn=123-45-6789"} {
+		assert.False(t, bytes.Contains(encoded, []byte(secret)), secret)
+	}
+
+	received := DecodeProto(encoded)
+	assert.Equal(t, Redacted(sent), received.Error())
+	assert.Equal(t, Redacted(sent), Redacted(received))
+	assert.Equal(t, "Account ab12 is locked.", UserMessage(received, "en"))
+	assert.Equal(t, []string{"retry after 30 seconds"}, Hints(received))
+	assert.Equal(t, []string{"request body: ‹×›"}, Details(received))
+	assert.Equal(t, encoded, EncodeProto(received))
+}
+
+func TestDecodeProto_Malformed(t *testing.T) {
+	for _, b := range [][]byte{
+		{0xff},                   // truncated tag
+		{0x08},                   // truncated varint
+		{0x12, 0x05, 0x0a},       // truncated layer
+		{0x0b},                   // unsupported wire type
+		{0x12, 0x00},             // missing version
+		{0x08, 0x02, 0x12, 0x00}, // unsupported version
+	} {
+		err := DecodeProto(b)
+		assert.ErrorIs(t, err, ErrMalformedProto, "% x", b)
+	}
+
+	deep := EncodeProto(New("leaf"))
+	for i := 0; i <= protoMaxDepth; i++ {
+		deep = EncodeProto(Append(New("branch"), Wrap(DecodeProto(deep), "nested")))
+	}
+	assert.ErrorIs(t, DecodeProto(deep), ErrMalformedProto)
+}
+
+// FuzzDecodeProto checks that decoding never panics, and that re-encoding a decoded error is
+// stable.
+func FuzzDecodeProto(f *testing.F) {
+	f.Add(EncodeProto(tryButFail()))
+	f.Add(EncodeProto(WithHint(WrapWithCode(errTestKind.New("x"), 5, "y"), "z")))
+	f.Add(EncodeProto(Append(New("a"), fmt.Errorf("b: %w", Append(Const("c"), io.EOF)))))
+	f.Fuzz(func(t *testing.T, b []byte) {
+		err := DecodeProto(b)
+		if err == nil || errors.Is(err, ErrMalformedProto) {
+			return
+		}
+		_ = fmt.Sprintf("%v %+v", err, err)
+		encoded := EncodeProto(err)
+		if again := EncodeProto(DecodeProto(encoded)); !bytes.Equal(encoded, again) {
+			t.Fatalf("re-encoding is not stable:\n% x\n% x", encoded, again)
+		}
+	})
+}
+
+// FuzzProtoRoundTrip checks that layers round-trip whatever their contents.
+func FuzzProtoRoundTrip(f *testing.F) {
+	f.Add("message %d", 404, "file.go", 12, "function", "hint")
+	f.Add("", -1, "", 0, "", "")
+	f.Fuzz(func(t *testing.T, msg string, code int, file string, line int, function, hint string) {
+		loc := Location{file, line, function}
+		var err error = TError{base: Const(msg), msg: msg, format: msg, loc: loc}
+		err = WithHint(codeError{err, code}, "%s", Safe(hint))
+		decoded := DecodeProto(EncodeProto(err))
+		assert.Equal(t, err.Error(), decoded.Error())
+		assert.Equal(t, fmt.Sprintf("%+v", err), fmt.Sprintf("%+v", decoded))
+		assert.Equal(t, code, GetCode(decoded))
+		var terr TError
+		if assert.ErrorAs(t, decoded, &terr) {
+			assert.Equal(t, loc, terr.Location())
+		}
+	})
+}
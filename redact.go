@@ -0,0 +1,257 @@
+package terror
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// RedactionMarker replaces sensitive information in redacted output.
+const RedactionMarker = "‹×›"
+
+// Arg marks a format argument as being safe or unsafe to include in redacted output. An Arg
+// formats exactly like the value it marks, so Error() and "%+v" are unaffected by marking.
+type Arg struct {
+	value interface{}
+	safe  bool
+}
+
+// Safe marks v as safe to include in redacted output, e.g. because it is an identifier or count
+// that cannot carry personal information.
+func Safe(v interface{}) Arg { return Arg{value: v, safe: true} }
+
+// Unsafe marks v as sensitive, so that it is always replaced by RedactionMarker in redacted output
+// regardless of the redaction policy.
+func Unsafe(v interface{}) Arg { return Arg{value: v, safe: false} }
+
+// Format implements fmt.Formatter by formatting the marked value with the same verb and flags.
+func (a Arg) Format(f fmt.State, c rune) {
+	fmt.Fprintf(f, origFormatString(f, c), a.value)
+}
+
+// RedactionPolicy reports whether an argument that was not marked with Safe or Unsafe may be
+// included in redacted output. It is set by Config.RedactionPolicy or WithRedactionPolicy.
+type RedactionPolicy func(arg interface{}) bool
+
+// DefaultRedactionPolicy treats every unmarked argument as unsafe.
+func DefaultRedactionPolicy(arg interface{}) bool { return false }
+
+var safeErrors struct {
+	sync.RWMutex
+	errs []error
+}
+
+// RegisterSafeErrors registers errors whose text may be included in redacted output, typically
+// sentinels such as io.EOF. The text of every other error not created by this package is redacted.
+func RegisterSafeErrors(errs ...error) {
+	safeErrors.Lock()
+	safeErrors.errs = append(safeErrors.errs, errs...)
+	safeErrors.Unlock()
+}
+
+// Redacted returns the one-line message of err, as returned by Error(), with all sensitive
+// information replaced by RedactionMarker. Arguments are redacted unless marked with Safe or
+// allowed by the redaction policy, and the text of errors not created by this package is redacted
+// unless it was registered with RegisterSafeErrors. If err is nil, Redacted returns "".
+func Redacted(err error) string {
+	var b strings.Builder
+	sep := ""
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		switch e := e.(type) { //nolint:errorlint
+		case TError:
+			if e.msg != "" {
+				b.WriteString(sep)
+				b.WriteString(e.redactedMsg())
+				sep = ": "
+			}
+			continue
+		case codeError, kindError, noter:
+			continue
+		}
+		// Any other error renders the rest of the chain itself.
+		b.WriteString(sep)
+		if multi, ok := e.(*multiError); ok { //nolint:errorlint
+			for i, err := range multi.errs {
+				if i > 0 {
+					b.WriteString("; ")
+				}
+				b.WriteString(Redacted(err))
+			}
+		} else {
+			b.WriteString(redactedText(e))
+		}
+		break
+	}
+	return b.String()
+}
+
+// redactedMsg returns the message of e with unsafe arguments replaced.
+func (e TError) redactedMsg() string {
+	return e.redaction.redact(e.msg, e.format)
+}
+
+// redactedText returns the text of an error not wrapped by this package, if it is known to be
+// safe.
+func redactedText(err error) string {
+	switch err.(type) { //nolint:errorlint
+	case Const, *Kind, remoteError, *remoteJoin:
+		// The text of decoded errors was redacted when they were encoded.
+		return err.Error()
+	}
+	safeErrors.RLock()
+	defer safeErrors.RUnlock()
+	for _, safe := range safeErrors.errs {
+		if sameError(err, safe) {
+			return err.Error()
+		}
+	}
+	return RedactionMarker
+}
+
+// sameError reports whether a and b are the same error value, without panicking on uncomparable
+// error types.
+func sameError(a, b error) bool {
+	ta := reflect.TypeOf(a)
+	return ta == reflect.TypeOf(b) && ta.Comparable() && a == b //nolint:errorlint
+}
+
+// redacted stands in for an unsafe argument, whatever verb it is formatted with.
+type redacted struct{}
+
+func (redacted) Format(f fmt.State, c rune) { fmt.Fprint(f, RedactionMarker) }
+
+// redaction is what redaction needs of the arguments of a message. It is captured when the error
+// is created, so that the arguments, which may be large or change later, are not retained.
+type redaction struct {
+	// msg is the message with unsafe arguments replaced by RedactionMarker, unless safe is nil.
+	msg string
+	// safe holds the arguments formatted with %v, by their position in the format, with those that
+	// are unsafe left empty. It is nil if every argument is unsafe, in which case msg is not
+	// rendered until it is needed.
+	safe []string
+	// args is the number of arguments.
+	args int
+}
+
+// unsafeRedactions are shared by the messages with up to 8 arguments that are all unsafe, so that
+// creating them allocates nothing for redaction.
+var unsafeRedactions = func() (r [9]redaction) {
+	for i := range r {
+		r[i].args = i
+	}
+	return r
+}()
+
+// newRedaction applies policy to args, the arguments that msg was rendered from with format. If
+// policy is nil, unmarked arguments are unsafe; unless some are marked, nothing is then rendered
+// until the redacted message is needed. It returns nil if there are no arguments.
+func newRedaction(msg, format string, args []interface{}, policy RedactionPolicy) *redaction {
+	if len(args) == 0 {
+		return nil
+	}
+	if policy == nil {
+		if !anyMarked(args) && strings.IndexByte(format, '*') < 0 {
+			if len(args) < len(unsafeRedactions) {
+				return &unsafeRedactions[len(args)]
+			}
+			return &redaction{args: len(args)}
+		}
+		policy = DefaultRedactionPolicy
+	}
+	r := &redaction{msg: msg, safe: make([]string, len(args)), args: len(args)}
+	var widths []bool
+	if strings.IndexByte(format, '*') >= 0 {
+		widths = widthArgs(format, len(args))
+	}
+	var replaced []interface{}
+	for i, arg := range args {
+		safe := false
+		if marked, ok := arg.(Arg); ok {
+			arg, safe = marked.value, marked.safe
+		} else {
+			safe = policy(arg)
+		}
+		if safe {
+			r.safe[i] = fmt.Sprint(arg)
+			continue
+		}
+		if widths != nil && widths[i] {
+			// Widths and precisions are kept, as they are needed to render the message.
+			continue
+		}
+		if replaced == nil {
+			replaced = append([]interface{}(nil), args...)
+		}
+		replaced[i] = redacted{}
+	}
+	if replaced != nil {
+		r.msg = fmt.Sprintf(format, replaced...)
+	}
+	return r
+}
+
+// redact returns msg, which was rendered from format, with unsafe arguments replaced.
+func (r *redaction) redact(msg, format string) string {
+	switch {
+	case r == nil:
+		return msg
+	case r.safe == nil:
+		unsafe := make([]interface{}, r.args)
+		for i := range unsafe {
+			unsafe[i] = redacted{}
+		}
+		return fmt.Sprintf(format, unsafe...)
+	}
+	return r.msg
+}
+
+// anyMarked reports whether any of args is marked with Safe or Unsafe.
+func anyMarked(args []interface{}) bool {
+	for _, arg := range args {
+		if _, ok := arg.(Arg); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// widthArgs reports which of the n arguments of format are consumed by a '*' as a width or
+// precision, following the rules of package fmt.
+func widthArgs(format string, n int) []bool {
+	widths := make([]bool, n)
+	argNum := 0
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+	verb:
+		for i++; i < len(format); i++ {
+			switch c := format[i]; {
+			case c == '[':
+				end := strings.IndexByte(format[i:], ']')
+				if end < 0 {
+					return widths
+				}
+				if index, err := strconv.Atoi(format[i+1 : i+end]); err == nil {
+					argNum = index - 1
+				}
+				i += end
+			case c == '*':
+				if argNum >= 0 && argNum < n {
+					widths[argNum] = true
+				}
+				argNum++
+			case strings.IndexByte("+-# 0.123456789", c) >= 0:
+			default:
+				if c != '%' {
+					argNum++
+				}
+				break verb
+			}
+		}
+	}
+	return widths
+}
@@ -0,0 +1,103 @@
+package terror
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ExampleRedacted() {
+	err := New("no account for %s", "gopher@example.com")
+	err = Wrap(err, "handling request %d", Safe(1234))
+	fmt.Println(err.Error())
+	fmt.Println(Redacted(err))
+	// Output:
+	// handling request 1234: no account for gopher@example.com
+	// handling request 1234: no account for ‹×›
+}
+
+func TestRedacted(t *testing.T) {
+	assert.Equal(t, "", Redacted(nil))
+
+	err := Wrap(errSentinel, "user %q has %d items (%s)", Unsafe("gopher"), Safe(3), "secret")
+	assert.Equal(t, `user "gopher" has 3 items (secret): some error`, err.Error())
+	assert.Equal(t, "user ‹×› has 3 items (‹×›): ‹×›", Redacted(err))
+
+	// Marking is invisible in the detailed format.
+	assert.Equal(t,
+		""+
+			`user "gopher" has 3 items (secret)`+"\n"+
+			fmt.Sprintf(" --- at %s ---\n", err.(TError).Location())+
+			"caused by some error",
+		fmt.Sprintf("%+v", err),
+	)
+
+	// Annotations, codes and kinds don't change the message.
+	err = WrapWithCode(Annotate(errTestKind.New("id %s", Safe("x"))), 3, "")
+	assert.Equal(t, "id x: not found", Redacted(err))
+	assert.Equal(t, "in test: test error", Redacted(Wrap(errTestError, "in test")))
+
+	// Foreign errors are redacted as a whole.
+	_, openErr := os.Open("/no/such/file")
+	err = Wrap(fmt.Errorf("reading: %w", Wrap(openErr, "opening")), "loading")
+	assert.Equal(t, "loading: ‹×›", Redacted(err))
+}
+
+func TestRedacted_Registration(t *testing.T) {
+	// A new sentinel keeps this test repeatable, since registration is permanent.
+	errSafe := errors.New("safe")
+	assert.Equal(t, "reading: ‹×›", Redacted(Wrap(errSafe, "reading")))
+	RegisterSafeErrors(errSafe, SomeCustomErrorWrapper{})
+	assert.Equal(t, "reading: safe", Redacted(Wrap(errSafe, "reading")))
+	assert.Equal(t, "reading: ‹×›", Redacted(Wrap(io.ErrShortWrite, "reading")))
+}
+
+func TestRedacted_Policy(t *testing.T) {
+	defer SetConfig(Config{})
+	ints := func(arg interface{}) bool {
+		_, ok := arg.(int)
+		return ok
+	}
+	SetConfig(Config{RedactionPolicy: ints})
+	assert.Equal(t, "3 of ‹×›: ‹×›", Redacted(New("%d of %s: %v", 3, "x", Unsafe(4))))
+
+	// Wrappers can have a policy of their own.
+	SetConfig(Config{})
+	w := NewWrapper(WithRedactionPolicy(ints))
+	assert.Equal(t, "3 of ‹×›", Redacted(w.New("%d of %s", 3, "x")))
+	assert.Equal(t, "‹×› of ‹×›", Redacted(New("%d of %s", 3, "x")))
+}
+
+func TestRedacted_Arguments(t *testing.T) {
+	// Messages without marked arguments are redacted when needed, whatever the number of arguments.
+	assert.Equal(t, "‹×›", Redacted(New("%s", "x")))
+	assert.Equal(t, "1=‹×› 2=‹×› 3=‹×› 4=‹×› 5=‹×› 6=‹×› 7=‹×› 8=‹×› 9=‹×› 100%",
+		Redacted(New("1=%d 2=%d 3=%d 4=%d 5=%d 6=%d 7=%d 8=%d 9=%[9]d 100%%", 1, 2, 3, 4, 5, 6, 7, 8, 9)))
+
+	// Widths and precisions are kept.
+	err := New("[%*d] [%-*.*f] [%[1]*d]", 5, 42, 8, 2, 3.14159)
+	assert.Equal(t, "[   42] [3.14    ] [   42]", err.Error())
+	assert.Equal(t, "[‹×›] [‹×›] [‹×›]", Redacted(err))
+	assert.Equal(t, []bool{true, false, true, true, false}, widthArgs("[%*d] [%-*.*f] [%[1]*d]", 5))
+	assert.Equal(t, []bool{false, true, false}, widthArgs("%% %v %[2]*[3]d", 3))
+}
+
+func TestRedacted_Snapshot(t *testing.T) {
+	// Arguments are not retained, so changing them later doesn't change the redacted message.
+	buf := []byte("first")
+	err := New("read %s from %s", Safe(buf), "secret")
+	copy(buf, "later")
+	assert.Equal(t, "read first from secret", err.Error())
+	assert.Equal(t, "read first from ‹×›", Redacted(err))
+
+	// The policy applies when the error is created.
+	defer SetConfig(Config{})
+	SetConfig(Config{RedactionPolicy: func(interface{}) bool { return true }})
+	err = New("%s", "allowed")
+	SetConfig(Config{})
+	assert.Equal(t, "allowed", Redacted(err))
+}
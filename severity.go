@@ -0,0 +1,122 @@
+package terror
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// SeverityLevel is how severe an error is, for logging and alerting code to decide how to report it
+// without parsing its message. More severe levels are greater.
+type SeverityLevel int
+
+// The severity levels, from least to most severe. The zero SeverityLevel is not a level.
+const (
+	SeverityInfo SeverityLevel = iota + 1
+	SeverityWarning
+	SeverityError
+	SeverityCritical
+)
+
+// String returns the name of the level, e.g. "warning".
+func (s SeverityLevel) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	case SeverityCritical:
+		return "critical"
+	}
+	return "SeverityLevel(" + strconv.Itoa(int(s)) + ")"
+}
+
+// WithSeverity marks err with the given severity. The mark adds nothing to Error(), and is shown in
+// the detailed format. If err is nil, WithSeverity returns nil.
+func WithSeverity(err error, severity SeverityLevel) error {
+	if err == nil {
+		return nil
+	}
+	return severityError{box(err), severity}
+}
+
+type severityError struct {
+	base     error
+	severity SeverityLevel
+}
+
+func (e severityError) Unwrap() error              { return unbox(e.base) }
+func (e severityError) Error() string              { return e.base.Error() }
+func (e severityError) note() string               { return "severity: " + e.severity.String() }
+func (e severityError) Format(f fmt.State, c rune) { formatNoted(e.note(), e.base, f, c) }
+
+var codeSeverities struct {
+	sync.RWMutex
+	levels map[int]SeverityLevel
+}
+
+// RegisterCodeSeverity registers the severity implied by an error code, whether added by
+// WrapWithCode, NewWithCode or a Kind. Severities marked with WithSeverity take precedence over
+// those implied by codes.
+func RegisterCodeSeverity(code int, severity SeverityLevel) {
+	codeSeverities.Lock()
+	if codeSeverities.levels == nil {
+		codeSeverities.levels = make(map[int]SeverityLevel)
+	}
+	codeSeverities.levels[code] = severity
+	codeSeverities.Unlock()
+}
+
+// codeSeverity returns the severity registered for code, or 0 if there is none.
+func codeSeverity(code int) SeverityLevel {
+	codeSeverities.RLock()
+	defer codeSeverities.RUnlock()
+	return codeSeverities.levels[code]
+}
+
+// Severity returns the most severe level marked with WithSeverity anywhere in the chain of err,
+// including every error combined in a multi-error. If there is none, it returns the most severe
+// level implied by a code in the chain, or else Config.DefaultSeverity. If err is nil, Severity
+// returns 0.
+func Severity(err error) SeverityLevel {
+	if err == nil {
+		return 0
+	}
+	marked, implied := severities(err)
+	switch {
+	case marked > 0:
+		return marked
+	case implied > 0:
+		return implied
+	}
+	return loadConfig().DefaultSeverity
+}
+
+// severities returns the most severe levels marked and implied by codes in the chain of err.
+func severities(err error) (marked, implied SeverityLevel) {
+	for ; err != nil; err = errors.Unwrap(err) {
+		switch e := err.(type) { //nolint:errorlint
+		case severityError:
+			marked = maxSeverity(marked, e.severity)
+		case coder:
+			implied = maxSeverity(implied, codeSeverity(e.errorCode()))
+		case interface{ Unwrap() []error }:
+			for _, err := range e.Unwrap() {
+				m, i := severities(err)
+				marked, implied = maxSeverity(marked, m), maxSeverity(implied, i)
+			}
+		}
+	}
+	return marked, implied
+}
+
+// maxSeverity returns the more severe of a and b.
+func maxSeverity(a, b SeverityLevel) SeverityLevel {
+	if a > b {
+		return a
+	}
+	return b
+}
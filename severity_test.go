@@ -0,0 +1,91 @@
+package terror
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ExampleSeverity() {
+	err := WithSeverity(New("disk %d%% full", 91), SeverityWarning)
+	fmt.Println(err.Error())
+	fmt.Println(Severity(err))
+	fmt.Println(Severity(Wrap(err, "checking disks")))
+	// Output:
+	// disk 91% full
+	// warning
+	// warning
+}
+
+func TestSeverity(t *testing.T) {
+	assert.Nil(t, WithSeverity(nil, SeverityCritical))
+	assert.Equal(t, SeverityLevel(0), Severity(nil))
+	assert.Equal(t, SeverityError, Severity(errSentinel))
+
+	err := WithSeverity(Wrap(WithSeverity(errSentinel, SeverityInfo), "inner"), SeverityWarning)
+	assert.Equal(t, SeverityWarning, Severity(err))
+	assert.Equal(t, SeverityCritical, Severity(Wrap(WithSeverity(err, SeverityCritical), "outer")))
+	assert.ErrorIs(t, err, errSentinel)
+	assert.Equal(t, "inner: some error", err.Error())
+	assert.Equal(t, Fingerprint(Wrap(errSentinel, "inner")), Fingerprint(WithSeverity(Wrap(errSentinel, "inner"), SeverityInfo)))
+
+	// The most severe level is taken from every error combined.
+	combined := Append(WithSeverity(errSentinel, SeverityInfo), WithSeverity(errors.New("other"), SeverityCritical))
+	assert.Equal(t, SeverityCritical, Severity(combined))
+}
+
+func TestSeverity_Codes(t *testing.T) {
+	RegisterCodeSeverity(4001, SeverityWarning)
+	RegisterCodeSeverity(4002, SeverityInfo)
+	kind := NewKind("throttled", 4002)
+
+	assert.Equal(t, SeverityWarning, Severity(NewWithCode(4001, "coded")))
+	assert.Equal(t, SeverityInfo, Severity(kind.New("slow down")))
+	assert.Equal(t, SeverityWarning, Severity(WrapWithCode(kind.New("slow down"), 4001, "retrying")))
+	// Codes without a registered severity have the default severity.
+	assert.Equal(t, SeverityError, Severity(NewWithCode(4003, "coded")))
+	// Marked severities take precedence over implied ones.
+	assert.Equal(t, SeverityInfo, Severity(WithSeverity(NewWithCode(4001, "coded"), SeverityInfo)))
+}
+
+func TestSeverity_Default(t *testing.T) {
+	defer SetConfig(Config{})
+	SetConfig(Config{DefaultSeverity: SeverityWarning})
+	assert.Equal(t, SeverityWarning, Severity(errSentinel))
+	assert.Equal(t, SeverityCritical, Severity(WithSeverity(errSentinel, SeverityCritical)))
+}
+
+func TestSeverity_Format(t *testing.T) {
+	err := Wrap(WithSeverity(tryButFail(), SeverityWarning), "outer")
+	assert.Regexp(t, ""+
+		`^outer\n`+
+		` --- at github.com/Tanium-OSS/terror/severity_test.go:\d+ \(TestSeverity_Format\) ---\n`+
+		` --- severity: warning ---\n`+
+		`caused by trying something\n`+
+		` --- at github.com/Tanium-OSS/terror/testdata_test.go:13 \(tryButFail\) ---\n`+
+		`caused by some error$`,
+		fmt.Sprintf("%+v", err))
+
+	assert.Equal(t, ""+
+		" --- severity: critical ---\n"+
+		"trying something\n"+
+		" --- at github.com/Tanium-OSS/terror/testdata_test.go:13 (tryButFail) ---\n"+
+		"caused by some error",
+		fmt.Sprintf("%+v", WithSeverity(tryButFail(), SeverityCritical)))
+	assert.Equal(t, "trying something: some error", fmt.Sprintf("%s", WithSeverity(tryButFail(), SeverityCritical)))
+	assert.Equal(t, fmt.Sprintf("%+v", err), fmt.Sprintf("%v", err))
+	assert.Contains(t, fmt.Sprintf("%v", WithSeverity(tryButFail(), SeverityCritical)), " --- severity: critical ---\n")
+
+	// Marks on errors not created by this package are shown before them.
+	assert.Regexp(t, ` --- at .* \(TestSeverity_Format\) ---\n --- severity: info ---\ncaused by some error$`,
+		fmt.Sprintf("%+v", Wrap(WithSeverity(errSentinel, SeverityInfo), "outer")))
+	assert.Equal(t, "outer: some error", Redacted(Wrap(WithSeverity(Const("some error"), SeverityInfo), "outer")))
+}
+
+func TestSeverityLevel_String(t *testing.T) {
+	assert.Equal(t, "info", SeverityInfo.String())
+	assert.Equal(t, "critical", SeverityCritical.String())
+	assert.Equal(t, "SeverityLevel(9)", SeverityLevel(9).String())
+}
@@ -0,0 +1,99 @@
+package terror
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// SourceFormatter returns a Formatter for development builds that, like DetailedFormat, writes the
+// message and location of each layer of an error, but also shows the source code surrounding each
+// location, with contextLines lines before and after the marked line:
+//
+//	terror.SetConfig(terror.Config{Formatter: terror.SourceFormatter(2)})
+//
+// Source files are read from disk at most once. A location whose source file cannot be found,
+// e.g. when running in a container without sources, is shown without source code. Module-relative
+// file names are resolved in the local module directories known to ModuleRelativeFileName, and
+// files in the module cache cannot be found unless FullFileName is used.
+func SourceFormatter(contextLines int) Formatter {
+	if contextLines < 0 {
+		contextLines = 0
+	}
+	return func(w io.Writer, err TError) {
+		err.detailedError(w, func(w io.Writer, loc Location) {
+			writeSource(w, loc, contextLines)
+		})
+	}
+}
+
+// writeSource writes the lines of source surrounding loc, if they can be found.
+func writeSource(w io.Writer, loc Location, contextLines int) {
+	lines := sourceLines(loc.File)
+	if loc.Line < 1 || loc.Line > len(lines) {
+		return
+	}
+	first, last := loc.Line-contextLines, loc.Line+contextLines
+	if first < 1 {
+		first = 1
+	}
+	if last > len(lines) {
+		last = len(lines)
+	}
+	width := len(fmt.Sprint(last))
+	for n := first; n <= last; n++ {
+		marker := " "
+		if n == loc.Line {
+			marker = ">"
+		}
+		line := fmt.Sprintf("\n    %s %*d | %s", marker, width, n, lines[n-1])
+		io.WriteString(w, strings.TrimRight(line, " \t\r"))
+	}
+}
+
+// sourceCache caches the lines of source files by location file name. Files that cannot be read
+// are cached as nil, so that they are not looked for again.
+var sourceCache sync.Map
+
+// sourceLines returns the lines of the source file that a location's file name refers to, or nil
+// if it cannot be found.
+func sourceLines(filename string) []string {
+	if lines, ok := sourceCache.Load(filename); ok {
+		return lines.([]string)
+	}
+	var lines []string
+	for _, name := range sourceCandidates(filename) {
+		if data, err := os.ReadFile(name); err == nil {
+			lines = strings.Split(string(bytes.TrimSuffix(data, []byte("\n"))), "\n")
+			break
+		}
+	}
+	sourceCache.Store(filename, lines)
+	return lines
+}
+
+// sourceCandidates returns the paths on disk that a location's file name may refer to, depending on
+// how it was cleaned. Names that are neither absolute nor module-relative, such as those cleaned by
+// BaseFileName, have no candidates, as a file of the same name elsewhere would show the wrong
+// source.
+func sourceCandidates(filename string) []string {
+	if filepath.IsAbs(filename) {
+		return []string{filename}
+	}
+	// A module-relative name can be resolved in the local directory of its module.
+	local := mainModule.Load()
+	if local == nil {
+		return nil
+	}
+	var candidates []string
+	for _, dep := range *local {
+		if rel := strings.TrimPrefix(filename, dep.path+"/"); rel != filename {
+			candidates = append(candidates, filepath.FromSlash(dep.dir+rel))
+		}
+	}
+	return candidates
+}
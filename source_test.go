@@ -0,0 +1,80 @@
+package terror
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSourceFormatter(t *testing.T) {
+	defer SetConfig(Config{})
+	SetConfig(Config{Formatter: SourceFormatter(1)})
+
+	assert.Equal(t,
+		""+
+			"trying something\n"+
+			" --- at github.com/Tanium-OSS/terror/testdata_test.go:13 (tryButFail) ---\n"+
+			"      12 | func failed() error                  { return errSentinel }\n"+
+			"    > 13 | func tryButFail() error              { return Wrap(failed(), \"trying something\") }\n"+
+			"      14 | func tryButFailf(param string) error { return Wrap(failed(), \"trying %s\", param) }\n"+
+			"caused by some error",
+		fmt.Sprintf("%+v", tryButFail()),
+	)
+
+	// Without context, only the marked line is shown for each layer.
+	SetConfig(Config{Formatter: SourceFormatter(0)})
+	assert.Equal(t,
+		""+
+			"panic\n"+
+			" --- at github.com/Tanium-OSS/terror/testdata_test.go:34 (panicError (closure #1)) ---\n"+
+			"    > 34 | \t\terr = Wrap(recover().(error), \"panic\")\n"+
+			"caused by error\n"+
+			" --- at github.com/Tanium-OSS/terror/testdata_test.go:36 (panicError) ---\n"+
+			"    > 36 | \tpanic(New(\"error\"))",
+		fmt.Sprintf("%+v", panicError()),
+	)
+}
+
+func TestSourceFormatter_Missing(t *testing.T) {
+	w := NewWrapper(
+		WithFormatter(SourceFormatter(2)),
+		WithFileNameCleaner(func(string) string { return "example.com/missing/file.go" }),
+	)
+	assert.Regexp(t, `^missing\n --- at example.com/missing/file.go:\d+ \(TestSourceFormatter_Missing\) ---$`,
+		fmt.Sprintf("%+v", w.New("missing")))
+}
+
+func TestSourceFormatter_FullFileName(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "short.go")
+	require.NoError(t, os.WriteFile(name, []byte("package short\n\nvar x = 1\n"), 0o600))
+
+	w := NewWrapper(WithFormatter(SourceFormatter(5)))
+	located := w.New("short").(TError) //nolint:errorlint
+	located.loc = Location{File: name, Line: 3, Function: "f"}
+	assert.Equal(t,
+		""+
+			"short\n"+
+			" --- at "+name+":3 (f) ---\n"+
+			"      1 | package short\n"+
+			"      2 |\n"+
+			"    > 3 | var x = 1",
+		fmt.Sprintf("%+v", located),
+	)
+
+	// A line beyond the end of the file, e.g. because it changed, shows no source.
+	located.loc.Line = 4
+	assert.Equal(t, "short\n --- at "+name+":4 (f) ---", fmt.Sprintf("%+v", located))
+}
+
+func TestSourceFormatter_BaseFileName(t *testing.T) {
+	// The working directory has a source.go, but it is not necessarily the file that was located.
+	w := NewWrapper(WithFormatter(SourceFormatter(1)), WithFileNameCleaner(BaseFileName))
+	assert.Regexp(t, `^base\n --- at source_test.go:\d+ \(TestSourceFormatter_BaseFileName\) ---$`,
+		fmt.Sprintf("%+v", w.New("base")))
+	assert.Empty(t, sourceCandidates("source.go"))
+}
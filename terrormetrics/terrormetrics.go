@@ -0,0 +1,183 @@
+// Package terrormetrics counts the errors created by the terror package, by the
+// code they were created with and the file and function that created them, to show which
+// call sites produce the most errors in production without scraping logs. The
+// counts are exposed through expvar and in the Prometheus text format:
+//
+//	metrics := terrormetrics.New(terrormetrics.Options{})
+//	metrics.Install()
+//	expvar.Publish("terror_errors", metrics)
+//	http.Handle("/metrics", metrics)
+package terrormetrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Tanium-OSS/terror"
+)
+
+// DefaultMaxSeries is the number of series counted when Options.MaxSeries is 0.
+const DefaultMaxSeries = 1000
+
+// Other is the code, file and function of the series that counts errors beyond
+// the series limit.
+const Other = "other"
+
+// The operations that errors are counted by.
+const (
+	// OperationNew counts errors created by New, NewWithCode or Kind.New.
+	OperationNew = "new"
+	// OperationWrap counts errors created by wrapping another error, with Wrap,
+	// WrapWithCode or Kind.Wrap.
+	OperationWrap = "wrap"
+)
+
+// Options configures Metrics.
+type Options struct {
+	// MaxSeries is the number of distinct combinations of operation, code, file
+	// and function that are counted. Errors that would add another series are
+	// counted with the code, file and function Other instead, so that errors created
+	// with unbounded codes or from generated functions cannot exhaust memory or
+	// overwhelm the monitoring system. It defaults to DefaultMaxSeries.
+	MaxSeries int
+}
+
+// Count is the number of errors counted in one series.
+type Count struct {
+	Operation string `json:"operation"`
+	// Code is the code the errors were created with, in decimal, or Other.
+	Code string `json:"code"`
+	// File is the file of the function that created the errors, as cleaned by
+	// terror.Config.CleanFileName, or Other. With the default
+	// terror.ModuleRelativeFileName it includes the package path, telling apart
+	// functions with the same name in different packages.
+	File string `json:"file"`
+	// Function is the function that created the errors, as cleaned by
+	// terror.Config.CleanFuncName, or Other.
+	Function string `json:"function"`
+	Count    uint64 `json:"count"`
+}
+
+// Metrics counts errors. It implements expvar.Var, so that it can be published
+// with expvar.Publish, and http.Handler, serving the counts in the Prometheus
+// text format. It is safe for concurrent use.
+type Metrics struct {
+	maxSeries int
+	mu        sync.Mutex
+	counts    map[series]uint64
+}
+
+type series struct {
+	operation, code, file, function string
+}
+
+// New creates Metrics with no counts. Call Install to count the errors created
+// from then on.
+func New(opts Options) *Metrics {
+	if opts.MaxSeries <= 0 {
+		opts.MaxSeries = DefaultMaxSeries
+	}
+	return &Metrics{maxSeries: opts.MaxSeries, counts: make(map[series]uint64)}
+}
+
+// Install sets terror.Config.OnCreate to m.Record, keeping the rest of the
+// configuration. If OnCreate is already set, it is called before m.Record, so
+// Metrics can be installed alongside other hooks, but installing the same
+// Metrics twice counts every error twice. Install reads and then replaces the
+// configuration, so it must not run concurrently with terror.SetConfig; call it
+// during initialization.
+func (m *Metrics) Install() {
+	c := terror.GetConfig()
+	if next := c.OnCreate; next != nil {
+		c.OnCreate = func(err terror.TError, code int, wrapped bool) {
+			next(err, code, wrapped)
+			m.Record(err, code, wrapped)
+		}
+	} else {
+		c.OnCreate = m.Record
+	}
+	terror.SetConfig(c)
+}
+
+// Record counts err, which was created with code. It has the signature of
+// terror.Config.OnCreate, so that it can be called by a hook that does more.
+func (m *Metrics) Record(err terror.TError, code int, wrapped bool) {
+	loc := err.Location()
+	s := series{OperationNew, strconv.Itoa(code), loc.File, loc.Function}
+	if wrapped {
+		s.operation = OperationWrap
+	}
+	m.mu.Lock()
+	if _, ok := m.counts[s]; !ok && len(m.counts) >= m.maxSeries {
+		s.code, s.file, s.function = Other, Other, Other
+	}
+	m.counts[s]++
+	m.mu.Unlock()
+}
+
+// Counts returns the counts of each series, from the greatest.
+func (m *Metrics) Counts() []Count {
+	m.mu.Lock()
+	counts := make([]Count, 0, len(m.counts))
+	for s, n := range m.counts {
+		counts = append(counts, Count{s.operation, s.code, s.file, s.function, n})
+	}
+	m.mu.Unlock()
+	sort.Slice(counts, func(i, j int) bool {
+		a, b := counts[i], counts[j]
+		switch {
+		case a.Count != b.Count:
+			return a.Count > b.Count
+		case a.File != b.File:
+			return a.File < b.File
+		case a.Function != b.Function:
+			return a.Function < b.Function
+		case a.Code != b.Code:
+			return a.Code < b.Code
+		}
+		return a.Operation < b.Operation
+	})
+	return counts
+}
+
+// String returns the counts as a JSON array, implementing expvar.Var.
+func (m *Metrics) String() string {
+	b, err := json.Marshal(m.Counts())
+	if err != nil {
+		return "null"
+	}
+	return string(b)
+}
+
+// WritePrometheus writes the counts to w in the Prometheus text exposition
+// format, as the counter terror_errors_total.
+func (m *Metrics) WritePrometheus(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("# HELP terror_errors_total Errors created by the terror package, by operation, code, file and function.\n")
+	b.WriteString("# TYPE terror_errors_total counter\n")
+	for _, c := range m.Counts() {
+		fmt.Fprintf(&b, "terror_errors_total{operation=%s,code=%s,file=%s,function=%s} %d\n",
+			labelValue(c.Operation), labelValue(c.Code), labelValue(c.File), labelValue(c.Function), c.Count)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// ServeHTTP serves the counts in the Prometheus text exposition format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = m.WritePrometheus(w)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// labelValue returns v quoted as a Prometheus label value.
+func labelValue(v string) string {
+	return `"` + labelEscaper.Replace(v) + `"`
+}
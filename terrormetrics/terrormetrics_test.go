@@ -0,0 +1,99 @@
+package terrormetrics
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Tanium-OSS/terror"
+	"github.com/stretchr/testify/assert"
+)
+
+var errNotFound = terror.NewKind("not found", 404)
+
+func fetch() error { return errNotFound.New("fetching") }
+
+func load() error { return terror.WrapWithCode(fetch(), 503, "loading") }
+
+func parse() error { return terror.New("parsing") }
+
+// file is the file of the functions above, as cleaned by default.
+const file = "github.com/Tanium-OSS/terror/terrormetrics/terrormetrics_test.go"
+
+// install installs m until the test ends.
+func install(t *testing.T, m *Metrics) {
+	m.Install()
+	t.Cleanup(func() { terror.SetConfig(terror.Config{}) })
+}
+
+func TestMetrics(t *testing.T) {
+	m := New(Options{})
+	install(t, m)
+	for i := 0; i < 3; i++ {
+		_ = load()
+	}
+	_ = parse()
+	_ = terror.Annotate(parse())
+
+	assert.Equal(t, []Count{
+		{OperationNew, "404", file, "fetch", 3},
+		{OperationWrap, "503", file, "load", 3},
+		{OperationNew, "0", file, "parse", 2},
+	}, m.Counts())
+
+	var b strings.Builder
+	assert.NoError(t, m.WritePrometheus(&b))
+	assert.Equal(t, ""+
+		"# HELP terror_errors_total Errors created by the terror package, by operation, code, file and function.\n"+
+		"# TYPE terror_errors_total counter\n"+
+		`terror_errors_total{operation="new",code="404",file="`+file+`",function="fetch"} 3`+"\n"+
+		`terror_errors_total{operation="wrap",code="503",file="`+file+`",function="load"} 3`+"\n"+
+		`terror_errors_total{operation="new",code="0",file="`+file+`",function="parse"} 2`+"\n",
+		b.String())
+
+	assert.JSONEq(t, `[
+		{"operation": "new", "code": "404", "file": "`+file+`", "function": "fetch", "count": 3},
+		{"operation": "wrap", "code": "503", "file": "`+file+`", "function": "load", "count": 3},
+		{"operation": "new", "code": "0", "file": "`+file+`", "function": "parse", "count": 2}
+	]`, m.String())
+	var _ expvar.Var = m
+}
+
+func TestMetrics_MaxSeries(t *testing.T) {
+	m := New(Options{MaxSeries: 2})
+	install(t, m)
+	_ = load()
+	_ = parse()
+	_ = terror.Wrap(parse(), "wrapped")
+	_ = parse()
+	assert.Equal(t, []Count{
+		{OperationNew, Other, Other, Other, 3},
+		{OperationNew, "404", file, "fetch", 1},
+		{OperationWrap, "503", file, "load", 1},
+		{OperationWrap, Other, Other, Other, 1},
+	}, m.Counts())
+}
+
+func TestMetrics_InstallChains(t *testing.T) {
+	var created int
+	terror.SetConfig(terror.Config{OnCreate: func(terror.TError, int, bool) { created++ }})
+	m := New(Options{})
+	install(t, m)
+	_ = parse()
+	assert.Equal(t, 1, created)
+	assert.Equal(t, []Count{{OperationNew, "0", file, "parse", 1}}, m.Counts())
+}
+
+func TestMetrics_ServeHTTP(t *testing.T) {
+	m := New(Options{})
+	m.Record(terror.New("created elsewhere").(terror.TError), 0, false) //nolint:errorlint
+	m.counts[series{OperationNew, "0", "", "quote\"back\\slash\nnewline"}] = 1
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), `terror_errors_total{operation="new",code="0",file="`+file+`",function="TestMetrics_ServeHTTP"} 1`)
+	assert.Contains(t, rec.Body.String(), `function="quote\"back\\slash\nnewline"} 1`)
+}
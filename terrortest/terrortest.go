@@ -0,0 +1,108 @@
+// Package terrortest provides test assertions for errors created by the terror
+// package that don't depend on exact line numbers or file paths, so that tests
+// of error paths don't break whenever unrelated code moves.
+package terrortest
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/Tanium-OSS/terror"
+)
+
+// AssertWrappedAt asserts that some layer of err was created or wrapped in
+// function, in a file whose name is file or ends with "/" followed by file. It
+// returns whether the assertion succeeded.
+func AssertWrappedAt(t testing.TB, err error, file, function string) bool {
+	t.Helper()
+	var seen []string
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		located, ok := e.(terror.Error) //nolint:errorlint
+		if !ok {
+			continue
+		}
+		loc := located.Location()
+		if loc.Function == function && (loc.File == file || strings.HasSuffix(loc.File, "/"+file)) {
+			return true
+		}
+		seen = append(seen, loc.String())
+	}
+	t.Errorf("error was not wrapped at %s (%s); it was wrapped at:\n\t%s", file, function, strings.Join(seen, "\n\t"))
+	return false
+}
+
+// AssertChainMessages asserts that the messages of the layers of err, from the
+// outermost to the root, are msgs. Layers that only add a location, such as
+// those created by terror.Annotate, have no message. The innermost error not
+// created by this package contributes its entire message, as returned by
+// Error(). It returns whether the assertion succeeded.
+func AssertChainMessages(t testing.TB, err error, msgs ...string) bool {
+	t.Helper()
+	got := ChainMessages(err)
+	if !equal(got, msgs) {
+		t.Errorf("unexpected error chain messages:\n\twant: %q\n\tgot:  %q", msgs, got)
+		return false
+	}
+	return true
+}
+
+// ChainMessages returns the messages of the layers of err, as compared by
+// AssertChainMessages.
+func ChainMessages(err error) []string {
+	var msgs []string
+	for e := err; e != nil; {
+		next := errors.Unwrap(e)
+		if layer, ok := e.(terror.TError); ok { //nolint:errorlint
+			if layer.Message() != "" {
+				msgs = append(msgs, layer.Message())
+			}
+		} else if next == nil || e.Error() != next.Error() {
+			// This error isn't merely annotating next, so its message
+			// includes that of next.
+			return append(msgs, e.Error())
+		}
+		e = next
+	}
+	return msgs
+}
+
+// AssertCode asserts that the error code of err, as returned by terror.GetCode,
+// is code. It returns whether the assertion succeeded.
+func AssertCode(t testing.TB, err error, code int) bool {
+	t.Helper()
+	if got := terror.GetCode(err); got != code {
+		t.Errorf("unexpected error code: want %d, got %d", code, got)
+		return false
+	}
+	return true
+}
+
+var locationPattern = regexp.MustCompile(` --- ((?:appended )?at) (?:\S*/)?([^/\s]+):\d+ `)
+
+// Normalize replaces the directories and line numbers of the locations in the
+// detailed format of an error, as printed by "%+v", including where errors were
+// combined by terror.Append, with placeholders so that it can be compared
+// against a golden string:
+//
+//	--- at github.com/foo/bar/file.go:37 (Func) ---
+//
+// becomes
+//
+//	--- at <path>/file.go:<line> (Func) ---
+func Normalize(detail string) string {
+	return locationPattern.ReplaceAllString(detail, " --- $1 <path>/$2:<line> ")
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
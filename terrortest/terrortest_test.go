@@ -0,0 +1,94 @@
+package terrortest
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/Tanium-OSS/terror"
+	"github.com/stretchr/testify/assert"
+)
+
+// recorder records the failures reported by an assertion.
+type recorder struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+var errRoot = errors.New("root")
+
+func readConfig() error {
+	_, err := os.ReadFile("/no/such/file")
+	return terror.WrapWithCode(err, 12, "reading config")
+}
+
+func TestAssertWrappedAt(t *testing.T) {
+	err := terror.Wrap(readConfig(), "starting")
+	AssertWrappedAt(t, err, "terrortest_test.go", "readConfig")
+	AssertWrappedAt(t, err, "terrortest/terrortest_test.go", "TestAssertWrappedAt")
+
+	r := &recorder{TB: t}
+	assert.False(t, AssertWrappedAt(r, err, "terrortest_test.go", "main"))
+	assert.False(t, AssertWrappedAt(r, err, "test.go", "readConfig"))
+	assert.Len(t, r.failures, 2)
+	assert.Contains(t, r.failures[0], "(readConfig)")
+}
+
+func TestAssertChainMessages(t *testing.T) {
+	err := terror.Wrap(terror.Annotate(terror.WrapWithCode(errRoot, 3, "b")), "a")
+	AssertChainMessages(t, err, "a", "b", "root")
+	AssertChainMessages(t, terror.New("only"), "only")
+	AssertChainMessages(t, terror.Wrap(readConfig(), "starting"),
+		"starting", "reading config", "open /no/such/file: no such file or directory")
+	AssertChainMessages(t, terror.Wrap(fmt.Errorf("foreign: %w", errRoot), "outer"),
+		"outer", "foreign: root")
+
+	r := &recorder{TB: t}
+	assert.False(t, AssertChainMessages(r, err, "a", "root"))
+	assert.False(t, AssertChainMessages(r, err, "a", "c", "root"))
+	assert.Len(t, r.failures, 2)
+}
+
+func TestAssertCode(t *testing.T) {
+	AssertCode(t, readConfig(), 12)
+	AssertCode(t, errRoot, 0)
+
+	r := &recorder{TB: t}
+	assert.False(t, AssertCode(r, readConfig(), 13))
+	assert.Equal(t, []string{"unexpected error code: want 13, got 12"}, r.failures)
+}
+
+func TestNormalize(t *testing.T) {
+	err := terror.Wrap(readConfig(), "starting")
+	assert.Equal(t,
+		""+
+			"starting\n"+
+			" --- at <path>/terrortest_test.go:<line> (TestNormalize) ---\n"+
+			"caused by reading config\n"+
+			" --- at <path>/terrortest_test.go:<line> (readConfig) ---\n"+
+			"caused by open /no/such/file: no such file or directory",
+		Normalize(fmt.Sprintf("%+v", err)),
+	)
+	assert.Equal(t, " --- at <path>/file.go:<line> (f) ---", Normalize(" --- at file.go:1 (f) ---"))
+}
+
+func TestNormalize_Appended(t *testing.T) {
+	err := terror.Append(terror.New("first"), errors.New("second"))
+	assert.Equal(t,
+		""+
+			"2 errors occurred:\n"+
+			" * first\n"+
+			"    --- at <path>/terrortest_test.go:<line> (TestNormalize_Appended) ---\n"+
+			"    --- appended at <path>/terrortest_test.go:<line> (TestNormalize_Appended) ---\n"+
+			" * second\n"+
+			"    --- appended at <path>/terrortest_test.go:<line> (TestNormalize_Appended) ---",
+		Normalize(fmt.Sprintf("%+v", err)),
+	)
+}
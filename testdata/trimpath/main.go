@@ -0,0 +1,13 @@
+// Command trimpath prints a located error, so that tests can compare the
+// output of binaries built with and without -trimpath.
+package main
+
+import (
+	"fmt"
+
+	"github.com/Tanium-OSS/terror"
+)
+
+func main() {
+	fmt.Printf("%+v\n", terror.New("located"))
+}
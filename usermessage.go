@@ -0,0 +1,176 @@
+package terror
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// GenericUserMessage is returned by UserMessage for errors without a user-facing message, unless a
+// generic message is registered for the locale with code 0.
+const GenericUserMessage = "An unexpected error occurred."
+
+// WithUserMessage annotates err with a message that is safe to show to end users, unlike Error(),
+// which includes every internal message. The message is not part of Error(); it is shown in the
+// detailed format and returned by UserMessage. If err is nil, WithUserMessage returns nil.
+func WithUserMessage(err error, msg string) error {
+	if err == nil {
+		return nil
+	}
+	return userMessageError{box(err), msg}
+}
+
+type userMessageError struct {
+	base error
+	msg  string
+}
+
+func (e userMessageError) Unwrap() error              { return unbox(e.base) }
+func (e userMessageError) Error() string              { return e.base.Error() }
+func (e userMessageError) note() string               { return "user message: " + e.msg }
+func (e userMessageError) Format(f fmt.State, c rune) { formatNoted(e.note(), e.base, f, c) }
+
+var userMessages struct {
+	sync.RWMutex
+	catalog map[userMessageKey]string
+}
+
+type userMessageKey struct {
+	code   int
+	locale string
+}
+
+// RegisterUserMessage registers the user-facing message for errors with the given code, whether
+// added by WrapWithCode, NewWithCode or a Kind, in the given locale, e.g. "en" or "pt-BR". A
+// message registered for the empty locale is used for locales without a message of their own, and a
+// message registered for code 0 is used for errors without a user-facing message.
+//
+// The message is a template: each placeholder {1}, {2} and so on is replaced by the format argument
+// at that position in the message of the layer that added the code, if it is marked with Safe or
+// allowed by the redaction policy. Placeholders of unsafe, empty or missing arguments are left as
+// they are:
+//
+//	terror.RegisterUserMessage(5001, "", "Account {2} is locked after {1} attempts.")
+//	err := terror.NewWithCode(5001, "%d attempts for %s", terror.Safe(5), terror.Safe("ab12"))
+//	terror.UserMessage(err, "en") // "Account ab12 is locked after 5 attempts."
+func RegisterUserMessage(code int, locale, msg string) {
+	userMessages.Lock()
+	if userMessages.catalog == nil {
+		userMessages.catalog = make(map[userMessageKey]string)
+	}
+	userMessages.catalog[userMessageKey{code, normalizeLocale(locale)}] = msg
+	userMessages.Unlock()
+}
+
+// UserMessage returns the outermost user-facing message in the chain of err: either a message added
+// by WithUserMessage, or the message registered for a code in the given locale. A locale without a
+// message falls back to its parent, e.g. "en" for "en-US", and then to the empty locale. If the
+// chain has no user-facing message, UserMessage returns the generic message registered for code 0,
+// or else GenericUserMessage. If err is nil, UserMessage returns "".
+func UserMessage(err error, locale string) string {
+	if err == nil {
+		return ""
+	}
+	locale = normalizeLocale(locale)
+	if msg, ok := userMessage(err, locale); ok {
+		return msg
+	}
+	if msg, ok := lookupUserMessage(0, locale); ok {
+		return msg
+	}
+	return GenericUserMessage
+}
+
+// userMessage returns the outermost user-facing message in the chain of err.
+func userMessage(err error, locale string) (string, bool) {
+	for ; err != nil; err = errors.Unwrap(err) {
+		switch e := err.(type) { //nolint:errorlint
+		case userMessageError:
+			return e.msg, true
+		case coder:
+			if msg, ok := lookupUserMessage(e.errorCode(), locale); ok {
+				return fillTemplate(msg, safeArgs(e)), true
+			}
+		case interface{ Unwrap() []error }:
+			for _, err := range e.Unwrap() {
+				if msg, ok := userMessage(err, locale); ok {
+					return msg, true
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+// lookupUserMessage returns the message registered for code in locale or the locales it falls back
+// to.
+func lookupUserMessage(code int, locale string) (string, bool) {
+	userMessages.RLock()
+	defer userMessages.RUnlock()
+	for {
+		if msg, ok := userMessages.catalog[userMessageKey{code, locale}]; ok {
+			return msg, true
+		}
+		if locale == "" {
+			return "", false
+		}
+		if i := strings.LastIndexByte(locale, '-'); i >= 0 {
+			locale = locale[:i]
+		} else {
+			locale = ""
+		}
+	}
+}
+
+// safeArgs returns the arguments of the message of the layer that the coder c added its code to,
+// by position, with those that are unsafe left empty.
+func safeArgs(c coder) []string {
+	var base error
+	switch c := c.(type) { //nolint:errorlint
+	case codeError:
+		base = c.base
+	case kindError:
+		base = c.base
+	}
+	if layer, ok := base.(TError); ok && layer.redaction != nil { //nolint:errorlint
+		return layer.redaction.safe
+	}
+	return nil
+}
+
+// fillTemplate replaces each placeholder {n} in msg with the nth of args, counting from 1.
+// Placeholders whose argument is empty or missing are left as they are.
+func fillTemplate(msg string, args []string) string {
+	if len(args) == 0 {
+		return msg
+	}
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(msg, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(msg[start:], '}')
+		if end < 0 {
+			break
+		}
+		end += start
+		b.WriteString(msg[:start])
+		if n, err := strconv.ParseUint(msg[start+1:end], 10, 0); err == nil && n >= 1 && n <= uint64(len(args)) && args[n-1] != "" {
+			b.WriteString(args[n-1])
+		} else {
+			b.WriteString(msg[start : end+1])
+		}
+		msg = msg[end+1:]
+	}
+	b.WriteString(msg)
+	return b.String()
+}
+
+// normalizeLocale returns locale in lower case with hyphens as separators, so that "pt_BR" and
+// "pt-br" are the same locale.
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+}
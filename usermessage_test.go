@@ -0,0 +1,97 @@
+package terror
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ExampleUserMessage() {
+	RegisterUserMessage(5001, "", "The account is locked.")
+	RegisterUserMessage(5001, "fr", "Le compte est verrouillé.")
+
+	err := Wrap(NewWithCode(5001, "account %d locked after %d attempts", 42, 5), "logging in")
+	fmt.Println(err.Error())
+	fmt.Println(UserMessage(err, "en-US"))
+	fmt.Println(UserMessage(err, "fr-CA"))
+	// Output:
+	// logging in: account 42 locked after 5 attempts
+	// The account is locked.
+	// Le compte est verrouillé.
+}
+
+func TestUserMessage(t *testing.T) {
+	assert.Nil(t, WithUserMessage(nil, "nothing"))
+	assert.Equal(t, "", UserMessage(nil, "en"))
+	assert.Equal(t, GenericUserMessage, UserMessage(tryButFail(), "en"))
+
+	err := WithUserMessage(Wrap(WithUserMessage(errSentinel, "inner"), "wrapped"), "Please try again.")
+	assert.EqualError(t, err, "wrapped: some error")
+	assert.ErrorIs(t, err, errSentinel)
+	assert.Equal(t, "Please try again.", UserMessage(err, "en"))
+	assert.Equal(t, "inner", UserMessage(errors.Unwrap(errors.Unwrap(err)), "en"))
+	assert.Equal(t, "wrapped: some error", Redacted(Wrap(WithUserMessage(Const("some error"), "Oops."), "wrapped")))
+
+	combined := Append(errSentinel, WithUserMessage(errSentinel, "second"))
+	assert.Equal(t, "second", UserMessage(combined, "en"))
+}
+
+func TestUserMessage_Catalog(t *testing.T) {
+	RegisterUserMessage(5002, "pt", "Tente novamente.")
+	RegisterUserMessage(5002, "pt_BR", "Tente de novo.")
+	kind := NewKind("rate limited", 5003)
+	RegisterUserMessage(5003, "", "Too many requests.")
+
+	err := NewWithCode(5002, "coded")
+	assert.Equal(t, "Tente de novo.", UserMessage(err, "pt-br"))
+	assert.Equal(t, "Tente novamente.", UserMessage(err, "pt-PT"))
+	assert.Equal(t, GenericUserMessage, UserMessage(err, "en"))
+
+	// The outermost message wins, whether added explicitly or by a code.
+	assert.Equal(t, "Too many requests.", UserMessage(kind.Wrap(err, "calling"), "pt-BR"))
+	assert.Equal(t, "Tente de novo.", UserMessage(WrapWithCode(kind.New("calling"), 5002, "retrying"), "pt-BR"))
+	assert.Equal(t, "Explicit.", UserMessage(WithUserMessage(err, "Explicit."), "pt-BR"))
+}
+
+func TestUserMessage_Template(t *testing.T) {
+	RegisterUserMessage(5004, "", "Account {1} is locked until {3}.")
+	RegisterUserMessage(5004, "fr", "Le compte {1} est verrouillé ({2}, {4}, {x}, {}).")
+	RegisterUserMessage(5005, "", "Rate limited: {1}.")
+	RegisterUserMessage(5007, "", "User {1}, order {2}")
+
+	// Placeholders are numbered by the position of the argument, and only safe arguments fill them.
+	err := NewWithCode(5004, "account %s locked after %d attempts until %s", Safe("ab12"), 5, Safe("noon"))
+	assert.Equal(t, "Account ab12 is locked until noon.", UserMessage(Wrap(err, "logging in"), "en"))
+	assert.Equal(t, "Le compte ab12 est verrouillé ({2}, {4}, {x}, {}).", UserMessage(err, "fr"))
+	assert.Equal(t, "Account {1} is locked until {3}.", UserMessage(NewWithCode(5004, "account %s locked", "ab12"), "en"))
+	err = NewWithCode(5007, "user %s order %s", "gopher@example.com", Safe("o-123"))
+	assert.Equal(t, "User {1}, order o-123", UserMessage(err, "en"))
+
+	// The arguments are those of the layer that added the code.
+	err = WrapWithCode(New("user %s", Safe("inner")), 5004, "outer %s", Safe("outer"))
+	assert.Equal(t, "Account outer is locked until {3}.", UserMessage(err, "en"))
+	assert.Equal(t, "Rate limited: calls.", UserMessage(NewKind("rate limited", 5005).New("%s", Safe("calls")), "en"))
+}
+
+func TestUserMessage_Generic(t *testing.T) {
+	defer func() {
+		userMessages.Lock()
+		delete(userMessages.catalog, userMessageKey{0, "de"})
+		userMessages.Unlock()
+	}()
+	RegisterUserMessage(0, "de", "Ein unerwarteter Fehler ist aufgetreten.")
+	assert.Equal(t, "Ein unerwarteter Fehler ist aufgetreten.", UserMessage(errSentinel, "de-AT"))
+	assert.Equal(t, GenericUserMessage, UserMessage(errSentinel, "en"))
+}
+
+func TestWithUserMessage_Format(t *testing.T) {
+	assert.Equal(t, ""+
+		" --- user message: Please try again. ---\n"+
+		"trying something\n"+
+		" --- at github.com/Tanium-OSS/terror/testdata_test.go:13 (tryButFail) ---\n"+
+		"caused by some error",
+		fmt.Sprintf("%+v", WithUserMessage(tryButFail(), "Please try again.")))
+	assert.Contains(t, fmt.Sprintf("%v", WithUserMessage(tryButFail(), "Please try again.")), " --- user message: Please try again. ---\n")
+}
@@ -0,0 +1,171 @@
+package terror
+
+// Wrapper creates errors like the package-level functions, but with its own configuration. This
+// allows libraries to configure how their errors are located and formatted without affecting the
+// application's use of SetConfig. Anything not configured by an Option follows the package
+// configuration.
+type Wrapper struct {
+	cfg Config
+}
+
+// std is used by the package-level functions. Being nil, it follows the package configuration
+// exclusively.
+var std *Wrapper
+
+// Option configures a Wrapper.
+type Option func(*Config)
+
+// WithFileNameCleaner sets the function used to sanitize file names, overriding
+// Config.CleanFileName.
+func WithFileNameCleaner(clean func(filename string) string) Option {
+	return func(c *Config) { c.CleanFileName = clean }
+}
+
+// WithFuncNameCleaner sets the function used to sanitize function names, overriding
+// Config.CleanFuncName.
+func WithFuncNameCleaner(clean func(funcName string) string) Option {
+	return func(c *Config) { c.CleanFuncName = clean }
+}
+
+// WithCaptureDepth sets the number of additional stack frames to skip when capturing the location
+// of the caller. Config.CaptureDepth does not apply to Wrappers.
+func WithCaptureDepth(depth int) Option {
+	return func(c *Config) { c.CaptureDepth = depth }
+}
+
+// WithFormatter sets the Formatter used by errors created by the Wrapper, overriding
+// Config.Formatter.
+func WithFormatter(formatter Formatter) Option {
+	return func(c *Config) { c.Formatter = formatter }
+}
+
+// WithLimits sets the Limits of errors created by the Wrapper, overriding Config.Limits.
+func WithLimits(limits Limits) Option {
+	return func(c *Config) { c.Limits = limits }
+}
+
+// WithPassthrough sets the errors that the Wrapper returns unchanged instead of wrapping,
+// overriding Config.Passthrough.
+func WithPassthrough(errs ...error) Option {
+	return func(c *Config) { c.Passthrough = append([]error{}, errs...) }
+}
+
+// WithCreationTime makes the Wrapper record when each error is created, as if
+// Config.RecordCreationTime were set.
+func WithCreationTime() Option {
+	return func(c *Config) { c.RecordCreationTime = true }
+}
+
+// WithRedactionPolicy sets the RedactionPolicy of errors created by the Wrapper, overriding
+// Config.RedactionPolicy.
+func WithRedactionPolicy(policy RedactionPolicy) Option {
+	return func(c *Config) { c.RedactionPolicy = policy }
+}
+
+// NewWrapper creates a Wrapper configured by opts.
+func NewWrapper(opts ...Option) *Wrapper {
+	w := &Wrapper{}
+	for _, opt := range opts {
+		opt(&w.cfg)
+	}
+	return w
+}
+
+// Wrap is like the package-level Wrap, but uses the configuration of w.
+func (w *Wrapper) Wrap(err error, format string, args ...interface{}) error {
+	if err == nil || w.passthrough(err, nil, 1) {
+		return err
+	}
+	return report(w.newError(err, format, args, 1), 0, true)
+}
+
+// Annotate is like the package-level Annotate, but uses the configuration of w.
+func (w *Wrapper) Annotate(err error) error {
+	if err == nil || w.passthrough(err, nil, 1) {
+		return err
+	}
+	return w.newError(err, "", nil, 1)
+}
+
+// WrapWithCode is like the package-level WrapWithCode, but uses the configuration of w.
+func (w *Wrapper) WrapWithCode(err error, code int, format string, args ...interface{}) error {
+	if err == nil || w.passthrough(err, nil, 1) {
+		return err
+	}
+	return codeError{report(w.newError(err, format, args, 1), code, true), code}
+}
+
+// New is like the package-level New, but uses the configuration of w.
+func (w *Wrapper) New(format string, args ...interface{}) error {
+	return report(w.newError(nil, format, args, 1), 0, false)
+}
+
+// NewWithCode is like the package-level NewWithCode, but uses the configuration of w.
+func (w *Wrapper) NewWithCode(code int, format string, args ...interface{}) error {
+	return codeError{report(w.newError(nil, format, args, 1), code, false), code}
+}
+
+// WrapInto is like the package-level WrapInto, but uses the configuration of w.
+func (w *Wrapper) WrapInto(pErr *error, format string, args ...interface{}) {
+	if *pErr == nil || w.passthrough(*pErr, nil, 1) {
+		return
+	}
+	*pErr = w.newError(*pErr, format, args, 1)
+}
+
+// formatter returns the Formatter for errors created by w, which may be nil for errors created by
+// the package-level functions.
+func (w *Wrapper) formatter() Formatter {
+	if w.hasFormatter() {
+		return w.cfg.Formatter
+	}
+	return loadConfig().Formatter
+}
+
+// hasFormatter reports whether w has a Formatter of its own.
+func (w *Wrapper) hasFormatter() bool {
+	return w != nil && w.cfg.Formatter != nil
+}
+
+// limits returns the Limits for errors created by w.
+func (w *Wrapper) limits() Limits {
+	if w != nil && w.cfg.Limits != (Limits{}) {
+		return w.cfg.Limits
+	}
+	return loadConfig().Limits
+}
+
+// passthrough reports whether err is one of targets or of the errors configured to be passed
+// through, and so is to be returned unchanged by the caller skip frames above the caller of
+// passthrough.
+func (w *Wrapper) passthrough(err error, targets []error, skip int) bool {
+	cfg := loadConfig()
+	configured := cfg.Passthrough
+	if w != nil && w.cfg.Passthrough != nil {
+		configured = w.cfg.Passthrough
+	}
+	for _, list := range [][]error{targets, configured} {
+		for _, target := range list {
+			if sameError(err, target) {
+				if cfg.OnPassthrough != nil {
+					cfg.OnPassthrough(err, w.capture(skip+1))
+				}
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// redactionPolicy returns the RedactionPolicy for errors created by w, or nil if none is set.
+func (w *Wrapper) redactionPolicy() RedactionPolicy {
+	if w != nil && w.cfg.RedactionPolicy != nil {
+		return w.cfg.RedactionPolicy
+	}
+	return loadConfig().RedactionPolicy
+}
+
+// recordsCreationTime reports whether errors created by w record when they were created.
+func (w *Wrapper) recordsCreationTime() bool {
+	return w != nil && w.cfg.RecordCreationTime || loadConfig().RecordCreationTime
+}
@@ -0,0 +1,77 @@
+package terror
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func wrapVia(w *Wrapper, err error) error { return w.Wrap(err, "via") }
+
+func TestWrapper(t *testing.T) {
+	defer SetConfig(Config{})
+
+	w := NewWrapper(
+		WithFileNameCleaner(BaseFileName),
+		WithFuncNameCleaner(func(name string) string { return "[" + cleanFuncName(name) + "]" }),
+	)
+	assert.Nil(t, w.Wrap(nil, "nothing"))
+	assert.Nil(t, w.Annotate(nil))
+	assert.Nil(t, w.WrapWithCode(nil, 1, "nothing"))
+
+	for _, err := range []error{
+		w.Wrap(errSentinel, "wrapped %d", 1),
+		w.Annotate(errSentinel),
+		w.WrapWithCode(errSentinel, 2, "coded"),
+		w.New("new %d", 3),
+		w.NewWithCode(4, "new"),
+	} {
+		var located Error
+		assert.True(t, errors.As(err, &located))
+		assert.Equal(t, "wrapper_test.go", located.Location().File)
+		assert.Equal(t, "[TestWrapper]", located.Location().Function)
+	}
+	assert.Equal(t, 2, GetCode(w.WrapWithCode(errSentinel, 2, "coded")))
+	assert.Equal(t, 4, GetCode(w.NewWithCode(4, "new")))
+
+	err := errSentinel
+	w.WrapInto(&err, "into %s", "err")
+	assert.EqualError(t, err, "into err: some error")
+	assert.Equal(t, "wrapper_test.go", err.(TError).Location().File)
+
+	// The package configuration is unaffected by the Wrapper...
+	assert.Equal(t, "github.com/Tanium-OSS/terror/testdata_test.go", tryButFail().(TError).Location().File)
+	// ...but does apply to anything the Wrapper doesn't override.
+	SetConfig(Config{CleanFuncName: func(string) string { return "global" }})
+	assert.Equal(t, "global", NewWrapper().New("").(TError).Location().Function)
+	assert.Equal(t, "[TestWrapper]", w.New("").(TError).Location().Function)
+}
+
+func TestWrapper_CaptureDepth(t *testing.T) {
+	defer SetConfig(Config{})
+	SetConfig(Config{CaptureDepth: 5})
+
+	assert.Equal(t, "wrapVia", wrapVia(NewWrapper(), errSentinel).(TError).Location().Function)
+	assert.Equal(t, "TestWrapper_CaptureDepth", wrapVia(NewWrapper(WithCaptureDepth(1)), errSentinel).(TError).Location().Function)
+}
+
+func TestWrapper_Formatter(t *testing.T) {
+	w := NewWrapper(WithFormatter(func(w io.Writer, err TError) {
+		io.WriteString(w, "custom: "+err.Error())
+	}))
+	err := w.Wrap(tryButFail(), "outer")
+	assert.Equal(t, "custom: outer: trying something: some error", fmt.Sprintf("%+v", err))
+	assert.Equal(t, "outer: trying something: some error", fmt.Sprintf("%s", err))
+	// Errors from elsewhere keep their own formatter.
+	outer := Wrap(err, "outer")
+	assert.Equal(t,
+		""+
+			"outer\n"+
+			fmt.Sprintf(" --- at %s ---\n", outer.(TError).Location())+
+			"caused by custom: outer: trying something: some error",
+		fmt.Sprintf("%+v", outer),
+	)
+}